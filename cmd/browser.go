@@ -2,9 +2,14 @@ package cmd
 
 import (
 	"errors"
+	"fmt"
+	"net/url"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"runtime"
 	"strings"
+	"time"
 )
 
 // ErrMockBrowser is used for testing browser errors
@@ -18,44 +23,434 @@ var getPlatform = func() string {
 	return runtime.GOOS
 }
 
+// lookPath searches PATH for a program, can be mocked for testing
+var lookPath = exec.LookPath
+
+// readOSRelease returns the kernel release string, can be mocked for testing
+var readOSRelease = func() (string, error) {
+	b, err := os.ReadFile("/proc/sys/kernel/osrelease")
+	return string(b), err
+}
+
+// launcherTimeout is how long a short-lived launcher is given to report a
+// failure. Some launchers keep running for as long as the browser they
+// started, so one that is still running after the timeout is assumed to have
+// succeeded.
+var launcherTimeout = 3 * time.Second
+
+// earlyExitTimeout is how long any other launcher, such as a browser, is given
+// to fail, e.g. on a bad option or a missing display. One that is still
+// running after it is assumed to have started.
+var earlyExitTimeout = 200 * time.Millisecond
+
+// shortLivedLaunchers are the programs that hand the URL to the desktop and
+// exit, which commandRunner waits for up to launcherTimeout to learn whether
+// they failed. Anything else keeps running and is only given earlyExitTimeout.
+var shortLivedLaunchers = map[string]bool{
+	"xdg-open": true,
+	"gio":      true,
+	"open":     true,
+	"wslview":  true,
+	"cmd":      true,
+	"cmd.exe":  true,
+	"rundll32": true,
+}
+
+// LauncherError is returned when a browser launcher cannot be started or exits
+// with a non-zero status.
+type LauncherError struct {
+	// Command is the launcher and its arguments.
+	Command []string
+	// ExitCode is the launcher's exit status, or -1 if it could not be started.
+	ExitCode int
+	// Stderr is what the launcher wrote to standard error.
+	Stderr string
+	// Err is the underlying error from starting or waiting for the launcher.
+	Err error
+}
+
+func (e *LauncherError) Error() string {
+	var msg string
+	if e.ExitCode < 0 {
+		msg = fmt.Sprintf("cannot run %s: %v", e.Command[0], e.Err)
+	} else {
+		msg = fmt.Sprintf("%s exited with status %d", e.Command[0], e.ExitCode)
+	}
+	if e.Stderr != "" {
+		msg += ": " + e.Stderr
+	}
+	return msg
+}
+
+func (e *LauncherError) Unwrap() error {
+	return e.Err
+}
+
 var commandRunner = func(name string, args ...string) error {
+	command := append([]string{name}, args...)
+	timeout := earlyExitTimeout
+	if shortLivedLaunchers[filepath.Base(name)] {
+		timeout = launcherTimeout
+	}
+
+	// Capture stderr in a file rather than a pipe: a launcher that outlives
+	// git-open (or the browser it spawned) must not die of SIGPIPE writing
+	// to it after we exit.
+	stderr, err := os.CreateTemp("", "git-open-launcher-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(stderr.Name())
+	defer stderr.Close()
+
 	cmd := exec.Command(name, args...)
-	// Redirect stdout and stderr to /dev/null to suppress output
+	// Discard stdout, the launcher's messages are not useful to the user
 	cmd.Stdout = nil
-	cmd.Stderr = nil
-	return cmd.Start()
+	cmd.Stderr = stderr
+	if err := cmd.Start(); err != nil {
+		return &LauncherError{Command: command, ExitCode: -1, Err: err}
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			return nil
+		}
+		launcherErr := &LauncherError{Command: command, ExitCode: -1, Err: err}
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			launcherErr.ExitCode = exitErr.ExitCode()
+		}
+		if b, readErr := os.ReadFile(stderr.Name()); readErr == nil {
+			launcherErr.Stderr = strings.TrimSpace(string(b))
+		}
+		return launcherErr
+	case <-time.After(timeout):
+		return nil
+	}
+}
+
+// foregroundRunner runs a launcher the user configured attached to the
+// terminal and waits for it, the way Python's webbrowser runs console
+// browsers: terminal browsers such as lynx or w3m need the terminal, and
+// graphical ones hand the URL to their running instance. It can be replaced
+// for testing.
+var foregroundRunner = func(name string, args ...string) error {
+	cmd := exec.Command(name, args...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	err := cmd.Run()
+	if err == nil {
+		return nil
+	}
+	launcherErr := &LauncherError{Command: append([]string{name}, args...), ExitCode: -1, Err: err}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		launcherErr.ExitCode = exitErr.ExitCode()
+	}
+	return launcherErr
 }
 
+// BrowserCommand is the browser command from the "browser" config key. It is
+// split into words the way a shell would, and every "{url}" placeholder is
+// replaced with the URL; without a placeholder the URL is appended.
 var BrowserCommand string
 
+// BrowserOverrides maps a host name to a browser command (in the same form as
+// BrowserCommand) used instead of BrowserCommand for URLs on that host or its
+// subdomains.
+var BrowserOverrides map[string]string
+
+// urlPlaceholder is replaced with the URL in a browser command template.
+const urlPlaceholder = "{url}"
+
+// browserEnvPlaceholder is replaced with the URL in entries of the BROWSER
+// environment variable, following Python's webbrowser module.
+const browserEnvPlaceholder = "%s"
+
+// launcher opens a URL in a browser.
+type launcher func(url string) error
+
+// openURLInBrowser tries each configured launcher, then the platform's
+// launchers, in turn until one succeeds. Without a display, errHeadless is
+// returned instead of trying the platform launchers.
 func openURLInBrowser(url string) error {
 	platform := getPlatform()
 
-	customBrowser := strings.TrimSpace(BrowserCommand)
-	if customBrowser != "" {
-		return commandRunner(customBrowser, url)
+	var errs []error
+	for _, open := range configuredLaunchers(url) {
+		err := open(url)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, err)
+	}
+
+	// Without a display the platform launcher would silently fail, so the
+	// URL is printed for the user instead
+	if isHeadless(platform) {
+		return errHeadless
+	}
+
+	launchers := platformLaunchers(platform)
+	if len(launchers) == 0 {
+		errs = append(errs, errors.New("unsupported platform: "+platform))
+		return errors.Join(errs...)
+	}
+	for _, open := range launchers {
+		err := open(url)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, err)
+	}
+	return fmt.Errorf("no browser launcher succeeded: %w", errors.Join(errs...))
+}
+
+// configuredLaunchers returns the launchers the user asked for: the browser
+// command from the config, then each entry of the BROWSER environment
+// variable, a list of commands separated like PATH in which "%s" stands for
+// the URL (the URL is appended to entries without it). They run in the
+// foreground with foregroundRunner.
+func configuredLaunchers(url string) []launcher {
+	var launchers []launcher
+	if customBrowser := browserCommandForURL(url); customBrowser != "" {
+		launchers = append(launchers, func(url string) error {
+			return openWithBrowserCommand(customBrowser, url)
+		})
+	}
+	for _, entry := range strings.Split(os.Getenv("BROWSER"), string(os.PathListSeparator)) {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		launchers = append(launchers, func(url string) error {
+			name, args, err := expandCommandTemplate(entry, browserEnvPlaceholder, url)
+			if err == nil {
+				err = foregroundRunner(name, args...)
+			}
+			if err != nil {
+				return fmt.Errorf("BROWSER entry %q: %w", entry, err)
+			}
+			return nil
+		})
+	}
+	return launchers
+}
+
+// platformLaunchers returns the launchers to try, in order, on platform.
+func platformLaunchers(platform string) []launcher {
+	switch platform {
+	case "linux":
+		// Inside WSL the Windows browser must be used
+		if isWSL() {
+			return wslLaunchers()
+		}
+		return []launcher{openWithXdgOpen, openWithGio, openWithXWWWBrowser}
+	case "darwin":
+		return []launcher{openWithMacOSOpen}
+	case "windows":
+		return []launcher{openWithWindowsStart, openWithRundll32}
+	}
+	return nil
+}
+
+// platformLauncherPrograms returns the programs the launchers of
+// platformLaunchers run, in the same order.
+func platformLauncherPrograms(platform string) []string {
+	switch platform {
+	case "linux":
+		if isWSL() {
+			if _, err := lookPath("wslview"); err == nil {
+				return []string{"wslview", "cmd.exe"}
+			}
+			return []string{"cmd.exe"}
+		}
+		return []string{"xdg-open", "gio", "x-www-browser"}
+	case "darwin":
+		return []string{"open"}
+	case "windows":
+		return []string{"cmd", "rundll32"}
+	}
+	return nil
+}
+
+// browserCommandForURL returns the configured browser command for rawURL,
+// preferring a per-host override over the default browser command. Of the
+// overrides for the host and the domains above it, the longest, most specific
+// one is used.
+func browserCommandForURL(rawURL string) string {
+	if parsed, err := url.Parse(rawURL); err == nil && parsed.Host != "" {
+		host := strings.ToLower(parsed.Hostname())
+		var match, command string
+		for overrideHost, overrideCommand := range BrowserOverrides {
+			overrideHost = strings.ToLower(overrideHost)
+			if overrideHost != host && !strings.HasSuffix(host, "."+overrideHost) {
+				continue
+			}
+			if overrideCommand = strings.TrimSpace(overrideCommand); overrideCommand == "" {
+				continue
+			}
+			// Ties are broken by the command so that the choice does not
+			// depend on the map order
+			if len(overrideHost) > len(match) || (len(overrideHost) == len(match) && overrideCommand < command) {
+				match, command = overrideHost, overrideCommand
+			}
+		}
+		if command != "" {
+			return command
+		}
 	}
+	return strings.TrimSpace(BrowserCommand)
+}
+
+// openWithBrowserCommand runs a browser command template for url.
+func openWithBrowserCommand(command, url string) error {
+	name, args, err := expandBrowserCommand(command, url)
+	if err != nil {
+		return err
+	}
+	return foregroundRunner(name, args...)
+}
 
-	// On Linux, use xdg-open with output redirection to suppress messages
-	if platform == "linux" {
-		return openWithXdgOpen(url)
+// expandBrowserCommand splits a browser command template into the program name
+// and its arguments, substituting url for "{url}" or appending it when the
+// template has no placeholder.
+func expandBrowserCommand(command, url string) (string, []string, error) {
+	return expandCommandTemplate(command, urlPlaceholder, url)
+}
+
+// expandCommandTemplate splits command into words and replaces placeholder in
+// each word with url, appending url when no word contains the placeholder.
+func expandCommandTemplate(command, placeholder, url string) (string, []string, error) {
+	words, err := splitCommandLine(command)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid browser command %q: %w", command, err)
 	}
-	// On macOS, use open command with output redirection to suppress messages
-	if platform == "darwin" {
-		return openWithMacOSOpen(url)
+	if len(words) == 0 {
+		return "", nil, errors.New("empty browser command")
 	}
-	// On Windows, use start command with output redirection to suppress messages
-	if platform == "windows" {
-		return openWithWindowsStart(url)
+
+	substituted := false
+	for i, word := range words {
+		if strings.Contains(word, placeholder) {
+			words[i] = strings.ReplaceAll(word, placeholder, url)
+			substituted = true
+		}
 	}
-	// For other platforms, return an error
-	return errors.New("unsupported platform: " + platform)
+	if !substituted {
+		words = append(words, url)
+	}
+	return words[0], words[1:], nil
+}
+
+// splitCommandLine splits s into words using POSIX shell quoting rules:
+// whitespace separates words, single quotes preserve everything literally,
+// double quotes allow backslash to escape `"`, `\`, `$` and "`", and a
+// backslash outside quotes escapes the next character.
+func splitCommandLine(s string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		case r == '\\':
+			inWord = true
+			if i+1 < len(runes) {
+				i++
+				word.WriteRune(runes[i])
+			}
+		case r == '\'':
+			inWord = true
+			closed := false
+			for i++; i < len(runes); i++ {
+				if runes[i] == '\'' {
+					closed = true
+					break
+				}
+				word.WriteRune(runes[i])
+			}
+			if !closed {
+				return nil, errors.New("unterminated single quote")
+			}
+		case r == '"':
+			inWord = true
+			closed := false
+			for i++; i < len(runes); i++ {
+				c := runes[i]
+				if c == '"' {
+					closed = true
+					break
+				}
+				if c == '\\' && i+1 < len(runes) && strings.ContainsRune("\"\\$`", runes[i+1]) {
+					i++
+					c = runes[i]
+				}
+				word.WriteRune(c)
+			}
+			if !closed {
+				return nil, errors.New("unterminated double quote")
+			}
+		default:
+			inWord = true
+			word.WriteRune(r)
+		}
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// isWSL reports whether the process runs inside the Windows Subsystem for
+// Linux, whose kernel release mentions Microsoft or WSL.
+func isWSL() bool {
+	release, err := readOSRelease()
+	if err != nil {
+		return false
+	}
+	release = strings.ToLower(release)
+	return strings.Contains(release, "microsoft") || strings.Contains(release, "wsl")
+}
+
+// wslLaunchers hands URLs to the Windows host, preferring wslview from wslu
+// and falling back to cmd.exe's start.
+func wslLaunchers() []launcher {
+	openWithCmdExe := func(url string) error {
+		return commandRunner("cmd.exe", "/c", "start", "", url)
+	}
+	if _, err := lookPath("wslview"); err == nil {
+		return []launcher{openWithWslview, openWithCmdExe}
+	}
+	return []launcher{openWithCmdExe}
+}
+
+func openWithWslview(url string) error {
+	return commandRunner("wslview", url)
 }
 
 func openWithXdgOpen(url string) error {
 	return commandRunner("xdg-open", url)
 }
 
+func openWithGio(url string) error {
+	return commandRunner("gio", "open", url)
+}
+
+func openWithXWWWBrowser(url string) error {
+	return commandRunner("x-www-browser", url)
+}
+
 func openWithMacOSOpen(url string) error {
 	return commandRunner("open", url)
 }
@@ -64,6 +459,10 @@ func openWithWindowsStart(url string) error {
 	return commandRunner("cmd", "/c", "start", "", url)
 }
 
+func openWithRundll32(url string) error {
+	return commandRunner("rundll32", "url.dll,FileProtocolHandler", url)
+}
+
 func openURLInBrowserFunc(url string) error {
 	return OpenURLInBrowser(url)
 }
@@ -1,9 +1,14 @@
 package cmd
 
 import (
+	"errors"
+	"os"
 	"os/exec"
+	"reflect"
 	"runtime"
+	"strings"
 	"testing"
+	"time"
 )
 
 // mockOpenURL is a mock function for testing
@@ -11,18 +16,20 @@ var mockOpenURL func(string) error
 
 func withNoopCommandRunner(t *testing.T) {
 	t.Helper()
+	withCommandRunner(t, func(name string, args ...string) error {
+		return nil
+	})
+}
+
+// withCommandRunner runs every launcher, in the background or the foreground,
+// with run for a test.
+func withCommandRunner(t *testing.T, run func(name string, args ...string) error) {
+	t.Helper()
+	original, originalForeground := commandRunner, foregroundRunner
 	t.Cleanup(func() {
-		commandRunner = func(name string, args ...string) error {
-			cmd := exec.Command(name, args...)
-			// Redirect stdout and stderr to /dev/null to suppress output
-			cmd.Stdout = nil
-			cmd.Stderr = nil
-			return cmd.Start()
-		}
+		commandRunner, foregroundRunner = original, originalForeground
 	})
-	commandRunner = func(name string, args ...string) error {
-		return nil
-	}
+	commandRunner, foregroundRunner = run, run
 }
 
 func Test_openURLInBrowser(t *testing.T) {
@@ -137,6 +144,9 @@ func Test_openURLInBrowser_PlatformSpecific(t *testing.T) {
 	defer func() {
 		OpenURLInBrowser = original
 	}()
+	// With a display, so that the platform launchers are tried
+	t.Setenv("SSH_CONNECTION", "")
+	t.Setenv("DISPLAY", ":0")
 
 	tests := []struct {
 		name        string
@@ -332,3 +342,513 @@ func Test_openURLInBrowser_UnsupportedPlatform(t *testing.T) {
 		})
 	}
 }
+
+func Test_splitCommandLine(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []string
+		wantErr bool
+	}{
+		{"single word", "firefox", []string{"firefox"}, false},
+		{"flags", "firefox --private-window", []string{"firefox", "--private-window"}, false},
+		{"double quotes", `open -a "Google Chrome"`, []string{"open", "-a", "Google Chrome"}, false},
+		{"single quotes", `open -a 'Google Chrome' {url}`, []string{"open", "-a", "Google Chrome", "{url}"}, false},
+		{"escaped space", `open -a Google\ Chrome`, []string{"open", "-a", "Google Chrome"}, false},
+		{"escaped quote in double quotes", `echo "a \"b\""`, []string{"echo", `a "b"`}, false},
+		{"windows path", `"C:\Program Files\Firefox\firefox.exe" -P work`, []string{`C:\Program Files\Firefox\firefox.exe`, "-P", "work"}, false},
+		{"extra whitespace", "  firefox \t -P  work ", []string{"firefox", "-P", "work"}, false},
+		{"empty quotes", `chrome ""`, []string{"chrome", ""}, false},
+		{"empty", "", nil, false},
+		{"unterminated double quote", `open -a "Google Chrome`, nil, true},
+		{"unterminated single quote", `open -a 'Google Chrome`, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := splitCommandLine(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("splitCommandLine(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitCommandLine(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_expandBrowserCommand(t *testing.T) {
+	const url = "https://github.com/zhaochunqi/git-open"
+
+	tests := []struct {
+		name     string
+		command  string
+		wantName string
+		wantArgs []string
+		wantErr  bool
+	}{
+		{"appends url", "firefox --private-window", "firefox", []string{"--private-window", url}, false},
+		{"placeholder", `open -a "Google Chrome" {url}`, "open", []string{"-a", "Google Chrome", url}, false},
+		{"placeholder inside word", "browser --url={url}", "browser", []string{"--url=" + url}, false},
+		{"empty command", "   ", "", nil, true},
+		{"invalid quoting", `open -a "Google Chrome`, "", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name, args, err := expandBrowserCommand(tt.command, url)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expandBrowserCommand() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if name != tt.wantName || !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("expandBrowserCommand() = %q %q, want %q %q", name, args, tt.wantName, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func Test_openURLInBrowser_CustomBrowser(t *testing.T) {
+	originalBrowserCommand := BrowserCommand
+	originalBrowserOverrides := BrowserOverrides
+	t.Cleanup(func() {
+		BrowserCommand = originalBrowserCommand
+		BrowserOverrides = originalBrowserOverrides
+	})
+
+	var gotCommand []string
+	withCommandRunner(t, func(name string, args ...string) error {
+		gotCommand = append([]string{name}, args...)
+		return nil
+	})
+
+	BrowserCommand = "firefox -P personal"
+	BrowserOverrides = map[string]string{
+		"github.example.com": `open -a "Google Chrome" --args --profile-directory=Work {url}`,
+		"example.com":        "chromium",
+	}
+
+	tests := []struct {
+		name string
+		url  string
+		want []string
+	}{
+		{
+			name: "default browser",
+			url:  "https://github.com/zhaochunqi/git-open",
+			want: []string{"firefox", "-P", "personal", "https://github.com/zhaochunqi/git-open"},
+		},
+		{
+			name: "host override",
+			url:  "https://GitHub.example.com/team/service",
+			want: []string{"open", "-a", "Google Chrome", "--args", "--profile-directory=Work", "https://GitHub.example.com/team/service"},
+		},
+		{
+			name: "subdomain override",
+			url:  "https://ci.example.com/job/service",
+			want: []string{"chromium", "https://ci.example.com/job/service"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotCommand = nil
+			if err := openURLInBrowser(tt.url); err != nil {
+				t.Fatalf("openURLInBrowser() error = %v", err)
+			}
+			if !reflect.DeepEqual(gotCommand, tt.want) {
+				t.Errorf("openURLInBrowser() ran %q, want %q", gotCommand, tt.want)
+			}
+		})
+	}
+}
+
+func Test_openURLInBrowser_BrowserEnv(t *testing.T) {
+	const url = "https://github.com/zhaochunqi/git-open"
+	sep := string(os.PathListSeparator)
+
+	tests := []struct {
+		name    string
+		env     string
+		missing []string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "single command",
+			env:  "firefox",
+			want: []string{"firefox", url},
+		},
+		{
+			name: "placeholder",
+			env:  "w3m -o confirm_qq=false %s",
+			want: []string{"w3m", "-o", "confirm_qq=false", url},
+		},
+		{
+			name:    "falls through to next entry",
+			env:     "missing-browser" + sep + "lynx %s",
+			missing: []string{"missing-browser"},
+			want:    []string{"lynx", url},
+		},
+		{
+			name:    "all entries fail falls back to platform launcher",
+			env:     "missing-browser" + sep + "other-missing-browser",
+			missing: []string{"missing-browser", "other-missing-browser"},
+			want:    []string{"open", url},
+		},
+		{
+			name:    "everything fails",
+			env:     "missing-browser",
+			missing: []string{"missing-browser", "open"},
+			wantErr: true,
+		},
+		{
+			name: "empty entries are skipped",
+			env:  sep + "firefox" + sep,
+			want: []string{"firefox", url},
+		},
+	}
+
+	originalGetPlatform := getPlatform
+	t.Cleanup(func() { getPlatform = originalGetPlatform })
+	getPlatform = func() string { return "darwin" }
+	t.Setenv("SSH_CONNECTION", "")
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("BROWSER", tt.env)
+
+			var gotCommand []string
+			withCommandRunner(t, func(name string, args ...string) error {
+				for _, missing := range tt.missing {
+					if name == missing {
+						return exec.ErrNotFound
+					}
+				}
+				gotCommand = append([]string{name}, args...)
+				return nil
+			})
+
+			err := openURLInBrowser(url)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("openURLInBrowser() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(gotCommand, tt.want) {
+				t.Errorf("openURLInBrowser() ran %q, want %q", gotCommand, tt.want)
+			}
+		})
+	}
+}
+
+func Test_isWSL(t *testing.T) {
+	originalReadOSRelease := readOSRelease
+	t.Cleanup(func() { readOSRelease = originalReadOSRelease })
+
+	tests := []struct {
+		name    string
+		release string
+		err     error
+		want    bool
+	}{
+		{"WSL2", "5.15.153.1-microsoft-standard-WSL2\n", nil, true},
+		{"WSL1", "4.4.0-19041-Microsoft\n", nil, true},
+		{"native Linux", "6.8.0-45-generic\n", nil, false},
+		{"unreadable", "", os.ErrNotExist, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			readOSRelease = func() (string, error) { return tt.release, tt.err }
+			if got := isWSL(); got != tt.want {
+				t.Errorf("isWSL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_openURLInBrowser_WSL(t *testing.T) {
+	const url = "https://github.com/zhaochunqi/git-open"
+
+	originalGetPlatform := getPlatform
+	originalReadOSRelease := readOSRelease
+	originalLookPath := lookPath
+	t.Cleanup(func() {
+		getPlatform = originalGetPlatform
+		readOSRelease = originalReadOSRelease
+		lookPath = originalLookPath
+	})
+	t.Setenv("BROWSER", "")
+	t.Setenv("SSH_CONNECTION", "")
+	getPlatform = func() string { return "linux" }
+	readOSRelease = func() (string, error) { return "5.15.153.1-microsoft-standard-WSL2", nil }
+
+	tests := []struct {
+		name       string
+		hasWslview bool
+		want       []string
+	}{
+		{"wslview", true, []string{"wslview", url}},
+		{"cmd.exe fallback", false, []string{"cmd.exe", "/c", "start", "", url}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lookPath = func(file string) (string, error) {
+				if tt.hasWslview && file == "wslview" {
+					return "/usr/bin/wslview", nil
+				}
+				return "", exec.ErrNotFound
+			}
+
+			var gotCommand []string
+			withCommandRunner(t, func(name string, args ...string) error {
+				gotCommand = append([]string{name}, args...)
+				return nil
+			})
+
+			if err := openURLInBrowser(url); err != nil {
+				t.Fatalf("openURLInBrowser() error = %v", err)
+			}
+			if !reflect.DeepEqual(gotCommand, tt.want) {
+				t.Errorf("openURLInBrowser() ran %q, want %q", gotCommand, tt.want)
+			}
+		})
+	}
+}
+
+func Test_commandRunner(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping test that needs a POSIX shell on Windows")
+	}
+
+	originalTimeout := launcherTimeout
+	t.Cleanup(func() { launcherTimeout = originalTimeout })
+	launcherTimeout = 2 * time.Second
+	withShortLivedLauncher(t, "sh")
+
+	tests := []struct {
+		name         string
+		command      []string
+		wantErr      bool
+		wantExitCode int
+		wantStderr   string
+	}{
+		{
+			name:    "success",
+			command: []string{"sh", "-c", "exit 0"},
+		},
+		{
+			name:         "failure with message",
+			command:      []string{"sh", "-c", "echo 'no handler for https' >&2; exit 4"},
+			wantErr:      true,
+			wantExitCode: 4,
+			wantStderr:   "no handler for https",
+		},
+		{
+			name:         "missing launcher",
+			command:      []string{"git-open-missing-launcher"},
+			wantErr:      true,
+			wantExitCode: -1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := commandRunner(tt.command[0], tt.command[1:]...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("commandRunner() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr {
+				return
+			}
+
+			var launcherErr *LauncherError
+			if !errors.As(err, &launcherErr) {
+				t.Fatalf("commandRunner() error = %T, want *LauncherError", err)
+			}
+			if launcherErr.ExitCode != tt.wantExitCode {
+				t.Errorf("LauncherError.ExitCode = %d, want %d", launcherErr.ExitCode, tt.wantExitCode)
+			}
+			if launcherErr.Stderr != tt.wantStderr {
+				t.Errorf("LauncherError.Stderr = %q, want %q", launcherErr.Stderr, tt.wantStderr)
+			}
+		})
+	}
+}
+
+func Test_commandRunner_StillRunningAfterTimeout(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping test that needs a POSIX shell on Windows")
+	}
+
+	originalTimeout := launcherTimeout
+	t.Cleanup(func() { launcherTimeout = originalTimeout })
+	launcherTimeout = 100 * time.Millisecond
+	withShortLivedLauncher(t, "sh")
+
+	// A launcher that keeps running (e.g. xdg-open waiting on the browser it
+	// started) is assumed to have succeeded.
+	if err := commandRunner("sh", "-c", "sleep 1"); err != nil {
+		t.Errorf("commandRunner() error = %v, want nil", err)
+	}
+}
+
+func Test_commandRunner_Browser(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping test that needs a POSIX shell on Windows")
+	}
+
+	// A browser keeps running, so it is only waited for briefly
+	start := time.Now()
+	if err := commandRunner("sh", "-c", "sleep 2; exit 1"); err != nil {
+		t.Errorf("commandRunner() error = %v, want nil", err)
+	}
+	if elapsed := time.Since(start); elapsed >= launcherTimeout || elapsed >= time.Second {
+		t.Errorf("commandRunner() took %v, want it to return once the browser started", elapsed)
+	}
+
+	// but failing at once, e.g. BROWSER=false, is reported
+	err := commandRunner("sh", "-c", "echo 'cannot open display' >&2; exit 1")
+	var launcherErr *LauncherError
+	if !errors.As(err, &launcherErr) || launcherErr.ExitCode != 1 || launcherErr.Stderr != "cannot open display" {
+		t.Errorf("commandRunner() error = %v, want the early exit reported", err)
+	}
+}
+
+func Test_foregroundRunner(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping test that needs a POSIX shell on Windows")
+	}
+
+	// A terminal browser is waited for until it exits
+	if err := foregroundRunner("sh", "-c", "sleep 0.3"); err != nil {
+		t.Errorf("foregroundRunner() error = %v, want nil", err)
+	}
+	err := foregroundRunner("sh", "-c", "exit 3")
+	var launcherErr *LauncherError
+	if !errors.As(err, &launcherErr) || launcherErr.ExitCode != 3 {
+		t.Errorf("foregroundRunner() error = %v, want exit status 3", err)
+	}
+}
+
+// withShortLivedLauncher makes commandRunner wait for name like for xdg-open.
+func withShortLivedLauncher(t *testing.T, name string) {
+	t.Helper()
+	shortLivedLaunchers[name] = true
+	t.Cleanup(func() { delete(shortLivedLaunchers, name) })
+}
+
+func Test_LauncherError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  *LauncherError
+		want string
+	}{
+		{
+			name: "exit status with stderr",
+			err:  &LauncherError{Command: []string{"xdg-open", "https://example.com"}, ExitCode: 3, Stderr: "no method available"},
+			want: "xdg-open exited with status 3: no method available",
+		},
+		{
+			name: "exit status without stderr",
+			err:  &LauncherError{Command: []string{"open", "https://example.com"}, ExitCode: 1},
+			want: "open exited with status 1",
+		},
+		{
+			name: "not started",
+			err:  &LauncherError{Command: []string{"wslview"}, ExitCode: -1, Err: exec.ErrNotFound},
+			want: "cannot run wslview: executable file not found in $PATH",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.err.Error(); got != tt.want {
+				t.Errorf("LauncherError.Error() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_openURLInBrowser_LauncherChain(t *testing.T) {
+	const url = "https://github.com/zhaochunqi/git-open"
+
+	originalGetPlatform := getPlatform
+	originalReadOSRelease := readOSRelease
+	originalBrowserCommand := BrowserCommand
+	t.Cleanup(func() {
+		getPlatform = originalGetPlatform
+		readOSRelease = originalReadOSRelease
+		BrowserCommand = originalBrowserCommand
+	})
+	t.Setenv("BROWSER", "")
+	t.Setenv("SSH_CONNECTION", "")
+	t.Setenv("DISPLAY", ":0")
+	getPlatform = func() string { return "linux" }
+	readOSRelease = func() (string, error) { return "6.8.0-45-generic", nil }
+
+	tests := []struct {
+		name        string
+		browser     string
+		failing     []string
+		wantTried   []string
+		wantErr     bool
+		wantErrText string
+	}{
+		{
+			name:      "first launcher succeeds",
+			wantTried: []string{"xdg-open"},
+		},
+		{
+			name:      "broken xdg-open falls back to gio",
+			failing:   []string{"xdg-open"},
+			wantTried: []string{"xdg-open", "gio"},
+		},
+		{
+			name:      "configured browser fails over to platform launchers",
+			browser:   "firefox",
+			failing:   []string{"firefox"},
+			wantTried: []string{"firefox", "xdg-open"},
+		},
+		{
+			name:        "all launchers fail",
+			failing:     []string{"xdg-open", "gio", "x-www-browser"},
+			wantTried:   []string{"xdg-open", "gio", "x-www-browser"},
+			wantErr:     true,
+			wantErrText: "x-www-browser exited with status 1: broken",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			BrowserCommand = tt.browser
+
+			var tried []string
+			withCommandRunner(t, func(name string, args ...string) error {
+				tried = append(tried, name)
+				for _, failing := range tt.failing {
+					if name == failing {
+						return &LauncherError{Command: append([]string{name}, args...), ExitCode: 1, Stderr: "broken"}
+					}
+				}
+				return nil
+			})
+
+			err := openURLInBrowser(url)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("openURLInBrowser() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(tried, tt.wantTried) {
+				t.Errorf("openURLInBrowser() tried %q, want %q", tried, tt.wantTried)
+			}
+			if tt.wantErr {
+				var launcherErr *LauncherError
+				if !errors.As(err, &launcherErr) {
+					t.Errorf("openURLInBrowser() error = %v, want a *LauncherError", err)
+				}
+				if !strings.Contains(err.Error(), tt.wantErrText) {
+					t.Errorf("openURLInBrowser() error = %v, want message containing %q", err, tt.wantErrText)
+				}
+			}
+		})
+	}
+}
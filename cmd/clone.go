@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/spf13/cobra"
+	"github.com/zhaochunqi/git-open/internal/homedir"
+)
+
+// CloneRoot is the directory repositories are cloned into, from the
+// "clone.root" config key.
+var CloneRoot string
+
+// CloneLayout is the Go template for the path of a clone below CloneRoot,
+// from the "clone.layout" config key.
+var CloneLayout string
+
+const (
+	defaultCloneRoot   = "~/src"
+	defaultCloneLayout = "{{.Host}}/{{.Owner}}/{{.Repo}}"
+)
+
+// gitRunner runs git attached to the terminal, can be mocked for testing
+var gitRunner = func(args ...string) error {
+	cmd := exec.Command("git", args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// cloneURLCmd represents the clone-url command
+var cloneURLCmd = &cobra.Command{
+	Use:   "clone-url <web-url>",
+	Short: "Print the clone URL for a repository web URL",
+	Long: `Convert a repository web URL, such as one copied from the browser, into the URL
+to clone it with. HTTPS is used unless --ssh is given.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		location, err := parseWebURL(args[0])
+		if err != nil {
+			return err
+		}
+
+		ssh, _ := cmd.Flags().GetBool("ssh")
+		fmt.Fprintln(cmd.OutOrStdout(), cloneURL(location, ssh))
+		return nil
+	},
+}
+
+// cloneCmd represents the clone command
+var cloneCmd = &cobra.Command{
+	Use:   "clone <web-url>",
+	Short: "Clone a repository from its web URL",
+	Long: `Clone the repository of a web URL into a directory laid out by the "clone.root"
+and "clone.layout" config keys (by default ~/src/<host>/<owner>/<repo>), and print
+the directory. HTTPS is used unless --ssh is given.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		location, err := parseWebURL(args[0])
+		if err != nil {
+			return err
+		}
+
+		dir, err := cloneDirectory(location)
+		if err != nil {
+			return err
+		}
+		if _, err := os.Stat(dir); err == nil {
+			return fmt.Errorf("destination %s already exists", dir)
+		}
+
+		ssh, _ := cmd.Flags().GetBool("ssh")
+		if err := gitRunner("clone", cloneURL(location, ssh), dir); err != nil {
+			return fmt.Errorf("error cloning %s: %w", location.Name(), err)
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), dir)
+		return nil
+	},
+}
+
+// cloneDirectory returns the directory location is cloned into, expanding
+// CloneLayout below CloneRoot with the location's host (without port), owner
+// and repository name. As these come from the URL, they may not have empty,
+// "." or ".." segments, and the directory must be below CloneRoot.
+func cloneDirectory(location *webLocation) (string, error) {
+	root, layout := CloneRoot, CloneLayout
+	if root == "" {
+		root = defaultCloneRoot
+	}
+	if layout == "" {
+		layout = defaultCloneLayout
+	}
+
+	tmpl, err := template.New("layout").Option("missingkey=error").Parse(layout)
+	if err != nil {
+		return "", fmt.Errorf("invalid clone layout %q: %w", layout, err)
+	}
+
+	fields := struct{ Host, Owner, Repo string }{hostWithoutPort(location.Host), location.Owner, location.Repo}
+	for _, field := range []string{fields.Host, fields.Owner, fields.Repo} {
+		if !validCloneSegments(field) {
+			return "", fmt.Errorf("cannot clone %s: invalid path segment in %q", location.Name(), field)
+		}
+	}
+	if strings.Contains(fields.Repo, "/") {
+		return "", fmt.Errorf("cannot clone %s: invalid repository name %q", location.Name(), fields.Repo)
+	}
+
+	var b strings.Builder
+	if err := tmpl.Execute(&b, fields); err != nil {
+		return "", fmt.Errorf("error executing clone layout %q: %w", layout, err)
+	}
+	root = filepath.Clean(homedir.Expand(root))
+	dir, err := pathBelowRoot(root, []string{filepath.FromSlash(b.String())})
+	if err != nil {
+		return "", fmt.Errorf("cannot clone %s: %w", location.Name(), err)
+	}
+	if dir == root {
+		return "", fmt.Errorf("cannot clone %s: clone layout %q gives the clone root itself", location.Name(), layout)
+	}
+	return dir, nil
+}
+
+// validCloneSegments reports whether the "/"-separated path has no empty, "."
+// or ".." segments, nor backslashes, which separate paths on Windows.
+func validCloneSegments(path string) bool {
+	if strings.Contains(path, `\`) {
+		return false
+	}
+	for _, segment := range strings.Split(path, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return false
+		}
+	}
+	return true
+}
+
+func init() {
+	for _, c := range []*cobra.Command{cloneURLCmd, cloneCmd} {
+		c.Flags().Bool("ssh", false, "Use the SSH clone URL.")
+		c.Flags().Bool("https", false, "Use the HTTPS clone URL (default).")
+		c.MarkFlagsMutuallyExclusive("ssh", "https")
+		rootCmd.AddCommand(c)
+	}
+}
@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func Test_cloneURLCmd(t *testing.T) {
+	tests := []struct {
+		name string
+		ssh  bool
+		want string
+	}{
+		{"https by default", false, "https://github.com/zhaochunqi/git-open.git\n"},
+		{"ssh", true, "git@github.com:zhaochunqi/git-open.git\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			cmd := &cobra.Command{}
+			cmd.SetOut(buf)
+			cmd.Flags().Bool("ssh", tt.ssh, "")
+
+			if err := cloneURLCmd.RunE(cmd, []string{"https://github.com/zhaochunqi/git-open/blob/main/go.mod#L3"}); err != nil {
+				t.Fatalf("cloneURLCmd.RunE() error = %v", err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("clone-url output = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_cloneDirectory(t *testing.T) {
+	originalRoot, originalLayout := CloneRoot, CloneLayout
+	t.Cleanup(func() { CloneRoot, CloneLayout = originalRoot, originalLayout })
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	location, err := parseWebURL("https://git.example.com:8443/group/sub/project")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		root    string
+		layout  string
+		want    string
+		wantErr bool
+	}{
+		{"defaults", "", "", filepath.Join(home, "src", "git.example.com", "group", "sub", "project"), false},
+		{"custom layout", "/work", "{{.Repo}}", filepath.Join("/work", "project"), false},
+		{"invalid layout", "/work", "{{.Nope}}", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			CloneRoot, CloneLayout = tt.root, tt.layout
+			got, err := cloneDirectory(location)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("cloneDirectory() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("cloneDirectory() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_cloneDirectory_OutsideRoot(t *testing.T) {
+	originalRoot, originalLayout := CloneRoot, CloneLayout
+	t.Cleanup(func() { CloneRoot, CloneLayout = originalRoot, originalLayout })
+	CloneRoot, CloneLayout = "/work", ""
+
+	tests := []struct {
+		name     string
+		location *webLocation
+		layout   string
+	}{
+		{"parent owner", &webLocation{Host: "github.com", Owner: "..", Repo: "repo"}, ""},
+		{"parent owner segment", &webLocation{Host: "gitlab.com", Owner: "group/../..", Repo: "repo"}, ""},
+		{"current repository", &webLocation{Host: "github.com", Owner: "owner", Repo: "."}, ""},
+		{"empty owner", &webLocation{Host: "github.com", Owner: "", Repo: "repo"}, ""},
+		{"empty owner segment", &webLocation{Host: "gitlab.com", Owner: "group//sub", Repo: "repo"}, ""},
+		{"repository with slash", &webLocation{Host: "github.com", Owner: "owner", Repo: "a/b"}, ""},
+		{"backslash", &webLocation{Host: "github.com", Owner: `..\..`, Repo: "repo"}, ""},
+		{"layout leaving the root", &webLocation{Host: "github.com", Owner: "owner", Repo: "repo"}, "../{{.Repo}}"},
+		{"layout giving the root", &webLocation{Host: "github.com", Owner: "owner", Repo: "repo"}, "."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			CloneLayout = tt.layout
+			if got, err := cloneDirectory(tt.location); err == nil {
+				t.Errorf("cloneDirectory() = %q, want an error", got)
+			}
+		})
+	}
+}
+
+func Test_cloneCmd(t *testing.T) {
+	originalRoot, originalLayout, originalRunner := CloneRoot, CloneLayout, gitRunner
+	t.Cleanup(func() { CloneRoot, CloneLayout, gitRunner = originalRoot, originalLayout, originalRunner })
+	CloneRoot, CloneLayout = t.TempDir(), ""
+
+	var gotArgs []string
+	gitRunner = func(args ...string) error {
+		gotArgs = args
+		return nil
+	}
+
+	buf := new(bytes.Buffer)
+	cmd := &cobra.Command{}
+	cmd.SetOut(buf)
+	cmd.Flags().Bool("ssh", true, "")
+
+	if err := cloneCmd.RunE(cmd, []string{"https://github.com/zhaochunqi/git-open"}); err != nil {
+		t.Fatalf("cloneCmd.RunE() error = %v", err)
+	}
+	dir := filepath.Join(CloneRoot, "github.com", "zhaochunqi", "git-open")
+	if want := []string{"clone", "git@github.com:zhaochunqi/git-open.git", dir}; !reflect.DeepEqual(gotArgs, want) {
+		t.Errorf("clone ran git %q, want %q", gotArgs, want)
+	}
+	if got := buf.String(); got != dir+"\n" {
+		t.Errorf("clone output = %q, want %q", got, dir+"\n")
+	}
+
+	// An existing destination is not cloned over.
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := cloneCmd.RunE(cmd, []string{"https://github.com/zhaochunqi/git-open"}); err == nil {
+		t.Error("cloneCmd.RunE() expected error for existing destination, got nil")
+	}
+}
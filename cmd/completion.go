@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/spf13/cobra"
+)
+
+// completionRepository returns the repository to complete arguments in.
+// Completions skip PersistentPreRunE, so the -C paths are applied here.
+func completionRepository() (*git.Repository, bool) {
+	if err := os.Chdir(startDir()); err != nil {
+		return nil, false
+	}
+	repo, err := getCurrentGitDirectoryFunc()
+	return repo, err == nil
+}
+
+// completeRemotes returns the names of the remotes of the repository that
+// start with toComplete.
+func completeRemotes(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	repo, ok := completionRepository()
+	if !ok {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	remotes, err := repo.Remotes()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var names []string
+	for _, remote := range remotes {
+		if name := remote.Config().Name; strings.HasPrefix(name, toComplete) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeTrackedFiles returns the tracked files below the current working
+// directory, relative to it, that start with toComplete.
+func completeTrackedFiles(toComplete string) []string {
+	repo, ok := completionRepository()
+	if !ok {
+		return nil
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		return nil
+	}
+	prefix, _, err := repositoryPath(wt.Filesystem.Root(), ".")
+	if err != nil {
+		return nil
+	}
+	if prefix != "" {
+		prefix += "/"
+	}
+	files, err := trackedFiles(repo)
+	if err != nil {
+		return nil
+	}
+
+	var paths []string
+	for _, file := range files {
+		if rel, ok := strings.CutPrefix(file, prefix); ok && strings.HasPrefix(rel, filepath.ToSlash(toComplete)) {
+			paths = append(paths, filepath.FromSlash(rel))
+		}
+	}
+	return paths
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/go-git/go-git/v5/config"
+	"github.com/spf13/cobra"
+	"github.com/zhaochunqi/git-open/internal/testhelper"
+)
+
+func Test_completions(t *testing.T) {
+	dir, cleanup := testhelper.SetupTestRepo(t, "git@github.com:owner/repo.git", "feature")
+	defer cleanup()
+
+	repo, err := getCurrentGitDirectory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.CreateRemote(&config.RemoteConfig{Name: "upstream", URLs: []string{"git@github.com:upstream/repo.git"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "docs"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"docs/guide.md", "docs/api.md"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+		wt, err := repo.Worktree()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := wt.Add(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("remotes", func(t *testing.T) {
+		got, directive := completeRemotes(rootCmd, nil, "")
+		if want := []string{"origin", "upstream"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("completeRemotes() = %q, want %q", got, want)
+		}
+		if directive != cobra.ShellCompDirectiveNoFileComp {
+			t.Errorf("completeRemotes() directive = %v, want NoFileComp", directive)
+		}
+	})
+
+	t.Run("tracked files", func(t *testing.T) {
+		if got, want := completeTrackedFiles("docs/g"), []string{filepath.Join("docs", "guide.md")}; !reflect.DeepEqual(got, want) {
+			t.Errorf("completeTrackedFiles() = %q, want %q", got, want)
+		}
+
+		if err := os.Chdir(filepath.Join(dir, "docs")); err != nil {
+			t.Fatal(err)
+		}
+		defer os.Chdir(dir)
+		if got, want := completeTrackedFiles(""), []string{"api.md", "guide.md"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("completeTrackedFiles() in docs = %q, want %q", got, want)
+		}
+	})
+}
@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/spf13/cobra"
+	"github.com/zhaochunqi/git-open/internal/testhelper"
+)
+
+// detachHead points HEAD of repo at the commit hash.
+func detachHead(t *testing.T, repo *git.Repository, hash plumbing.Hash) {
+	t.Helper()
+	if err := repo.Storer.SetReference(plumbing.NewHashReference(plumbing.HEAD, hash)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_rootCmd_DetachedHead(t *testing.T) {
+	tests := []struct {
+		name      string
+		remoteURL string
+		tag       string
+		annotated bool
+		want      string
+	}{
+		{"GitHub tag", "git@github.com:owner/repo.git", "v1.2.3", false, "https://github.com/owner/repo/releases/tag/v1.2.3"},
+		{"annotated tag", "git@github.com:owner/repo.git", "v2.0.0", true, "https://github.com/owner/repo/releases/tag/v2.0.0"},
+		{"GitLab tag", "https://gitlab.com/group/repo.git", "v1.2.3", false, "https://gitlab.com/group/repo/-/tags/v1.2.3"},
+		{"Bitbucket tag", "git@bitbucket.org:owner/repo.git", "v1.2.3", false, "https://bitbucket.org/owner/repo/src/v1.2.3"},
+		{"commit", "git@github.com:owner/repo.git", "", false, "https://github.com/owner/repo/tree/SHA"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, cleanup := testhelper.SetupTestRepo(t, tt.remoteURL, "main")
+			defer cleanup()
+
+			repo, err := getCurrentGitDirectory()
+			if err != nil {
+				t.Fatal(err)
+			}
+			head, err := repo.Head()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.tag != "" {
+				var opts *git.CreateTagOptions
+				if tt.annotated {
+					opts = &git.CreateTagOptions{Message: "release", Tagger: &object.Signature{Name: "Test", Email: "test@example.com", When: time.Now()}}
+				}
+				if _, err := repo.CreateTag(tt.tag, head.Hash(), opts); err != nil {
+					t.Fatal(err)
+				}
+			}
+			detachHead(t, repo, head.Hash())
+
+			buf := new(bytes.Buffer)
+			cmd := &cobra.Command{}
+			cmd.SetOut(buf)
+			cmd.SetErr(new(bytes.Buffer))
+			cmd.Flags().Bool("plain", true, "")
+			if err := rootCmd.RunE(cmd, []string{}); err != nil {
+				t.Fatalf("rootCmd.RunE() error = %v", err)
+			}
+			want := "Web URL: " + strings.Replace(tt.want, "SHA", head.Hash().String(), 1) + "\n"
+			if buf.String() != want {
+				t.Errorf("rootCmd output = %q, want %q", buf.String(), want)
+			}
+		})
+	}
+}
+
+func Test_rootCmd_JSON(t *testing.T) {
+	_, cleanup := testhelper.SetupTestRepo(t, "git@github.com:owner/repo.git", "main")
+	defer cleanup()
+
+	run := func() *Target {
+		t.Helper()
+		buf := new(bytes.Buffer)
+		cmd := &cobra.Command{}
+		cmd.SetOut(buf)
+		cmd.SetErr(new(bytes.Buffer))
+		cmd.Flags().Bool("json", true, "")
+		if err := rootCmd.RunE(cmd, []string{}); err != nil {
+			t.Fatalf("rootCmd.RunE() error = %v", err)
+		}
+		var target Target
+		if err := json.Unmarshal(buf.Bytes(), &target); err != nil {
+			t.Fatalf("rootCmd output %q is not JSON: %v", buf.String(), err)
+		}
+		return &target
+	}
+
+	got := run()
+	if got.Branch != "main" || got.Detached || got.WebURL != "https://github.com/owner/repo" {
+		t.Errorf("rootCmd JSON = %+v, want main on its home page", got)
+	}
+
+	repo, err := getCurrentGitDirectory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.CreateTag("v1.0.0", head.Hash(), nil); err != nil {
+		t.Fatal(err)
+	}
+	detachHead(t, repo, head.Hash())
+
+	got = run()
+	if got.Branch != "" || !got.Detached || got.Tag != "v1.0.0" || got.SHA != head.Hash().String() {
+		t.Errorf("rootCmd JSON = %+v, want a detached HEAD at v1.0.0", got)
+	}
+	if got.WebURL != "https://github.com/owner/repo/releases/tag/v1.0.0" {
+		t.Errorf("rootCmd JSON webURL = %q, want the tag page", got.WebURL)
+	}
+}
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+func Test_getCurrentGitDirectory_GitDirEnvironment(t *testing.T) {
+	root := t.TempDir()
+	gitDir := filepath.Join(root, "dotfiles.git")
+	bare, err := git.PlainInit(gitDir, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := bare.CreateRemote(&config.RemoteConfig{Name: "origin", URLs: []string{"git@github.com:owner/dotfiles.git"}}); err != nil {
+		t.Fatal(err)
+	}
+	nonBareDir := filepath.Join(root, "other")
+	initRepoWithRemote(t, nonBareDir, "git@github.com:owner/other.git")
+	home := filepath.Join(root, "home")
+	if err := os.MkdirAll(home, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	origDir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(home); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(origDir) })
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		gitDir       string
+		workTree     string
+		wantRemote   string
+		wantWorktree string
+	}{
+		{"bare repository with work tree", gitDir, home, "git@github.com:owner/dotfiles.git", home},
+		{"bare repository", gitDir, "", "git@github.com:owner/dotfiles.git", ""},
+		{"non-bare repository uses the current directory", filepath.Join(nonBareDir, ".git"), "", "git@github.com:owner/other.git", cwd},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("GIT_DIR", tt.gitDir)
+			t.Setenv("GIT_WORK_TREE", tt.workTree)
+
+			repo, err := getCurrentGitDirectory()
+			if err != nil {
+				t.Fatalf("getCurrentGitDirectory() error = %v", err)
+			}
+			remoteURL, err := getRemoteURL(repo)
+			if err != nil || remoteURL != tt.wantRemote {
+				t.Errorf("getRemoteURL() = %q, %v, want %q", remoteURL, err, tt.wantRemote)
+			}
+
+			root, err := worktreeRoot(repo)
+			if tt.wantWorktree == "" {
+				if !errors.Is(err, git.ErrIsBareRepository) {
+					t.Errorf("worktreeRoot() = %q, %v, want bare repository error", root, err)
+				}
+			} else if err != nil || root != tt.wantWorktree {
+				t.Errorf("worktreeRoot() = %q, %v, want %q", root, err, tt.wantWorktree)
+			}
+		})
+	}
+
+	t.Run("missing git directory", func(t *testing.T) {
+		t.Setenv("GIT_DIR", filepath.Join(root, "missing"))
+		t.Setenv("GIT_WORK_TREE", "")
+		if _, err := getCurrentGitDirectory(); !errors.Is(err, git.ErrRepositoryNotExists) {
+			t.Errorf("getCurrentGitDirectory() error = %v, want %v", err, git.ErrRepositoryNotExists)
+		}
+	})
+}
+
+func Test_openRepository_Bare(t *testing.T) {
+	root := t.TempDir()
+	bareDir := filepath.Join(root, "repo.git")
+	bare, err := git.PlainInit(bareDir, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := bare.CreateRemote(&config.RemoteConfig{Name: "origin", URLs: []string{"git@github.com:owner/repo.git"}}); err != nil {
+		t.Fatal(err)
+	}
+	// HEAD names a branch that has no commits yet
+	if err := bare.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, "refs/heads/trunk")); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{bareDir, filepath.Join(bareDir, "refs", "heads")} {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			repo, err := openRepository(path)
+			if err != nil {
+				t.Fatalf("openRepository() error = %v", err)
+			}
+			if remoteURL, err := getRemoteURL(repo); err != nil || remoteURL != "git@github.com:owner/repo.git" {
+				t.Errorf("getRemoteURL() = %q, %v, want git@github.com:owner/repo.git", remoteURL, err)
+			}
+			if branch, err := getBranchName(repo); err != nil || branch != "trunk" {
+				t.Errorf("getBranchName() = %q, %v, want trunk", branch, err)
+			}
+			if _, err := repo.Worktree(); !errors.Is(err, git.ErrIsBareRepository) {
+				t.Errorf("Worktree() error = %v, want %v", err, git.ErrIsBareRepository)
+			}
+		})
+	}
+
+	t.Run("plain directory", func(t *testing.T) {
+		plain := filepath.Join(root, "plain")
+		if err := os.MkdirAll(plain, 0755); err != nil {
+			t.Fatal(err)
+		}
+		t.Setenv("GIT_CEILING_DIRECTORIES", root)
+		if _, err := openRepository(plain); !errors.Is(err, git.ErrRepositoryNotExists) {
+			t.Errorf("openRepository() error = %v, want %v", err, git.ErrRepositoryNotExists)
+		}
+	})
+}
@@ -0,0 +1,304 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/zhaochunqi/git-open/pkg/gitopen"
+)
+
+// doctorCmd represents the doctor command
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Show how git-open sees the repository",
+	Long: `Report how git-open finds the Git repository in the current working directory,
+its remotes with their web URLs and providers, the settings in effect and where
+they come from, the config files read and the browser launcher that would open
+the page. When git-open opens the wrong page, include this in the bug report.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tw := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+		webURL, settings := writeRepositoryReport(tw)
+		writeConfigReport(tw)
+		writeBrowserReport(tw, webURL, settings)
+		return tw.Flush()
+	},
+}
+
+// reportRow writes an indented "key value" row of a doctor report section.
+func reportRow(w io.Writer, key, format string, args ...any) {
+	fmt.Fprintf(w, "  %s\t%s\n", key, fmt.Sprintf(format, args...))
+}
+
+// writeRepositoryReport writes how the repository is found, its remotes and
+// its settings, and returns the web URL that would be opened and the settings.
+func writeRepositoryReport(w io.Writer) (string, repoSettings) {
+	fmt.Fprintln(w, "Repository")
+	repo, err := getCurrentGitDirectory()
+	if err != nil {
+		reportRow(w, "found", "no: %v", err)
+		return "", yamlSettings("")
+	}
+	reportRow(w, "found", "%s", discoveryDescription())
+
+	gitDir := gitopen.GitDir(repo)
+	_, err = repo.Worktree()
+	fast, err := newFastRepository(gitDir, errors.Is(err, git.ErrIsBareRepository))
+	if err != nil {
+		reportRow(w, "git dir", "%s (config unreadable: %v)", gitDir, err)
+		return "", yamlSettings(gitDir)
+	}
+	reportRow(w, "git dir", "%s", gitDir)
+	if fast.commonDir != gitDir {
+		reportRow(w, "common dir", "%s (from commondir)", fast.commonDir)
+	}
+	reportRow(w, "storage", "%s", storageDescription(repo, fast.config))
+	reportRow(w, "HEAD", "%s", headDescription(repo, fast))
+
+	settings, err := fast.settings()
+	if err != nil {
+		reportRow(w, "settings", "invalid: %v", err)
+		settings = yamlSettings(fast.commonDir)
+	}
+
+	fmt.Fprintln(w, "Remotes")
+	names := fast.config.RemoteNames()
+	if len(names) == 0 {
+		reportRow(w, "none", "")
+	}
+	for _, name := range names {
+		rawURL, _ := fast.config.Get("remote", name, "url")
+		label := name
+		if name == settings.Remote {
+			label += " (opened)"
+		}
+		reportRow(w, label, "%s", rawURL)
+		remoteURL := fast.config.RewriteURL(rawURL)
+		if remoteURL != rawURL {
+			reportRow(w, "  rewritten", "%s (url.<base>.insteadOf)", remoteURL)
+		}
+		if resolved, ok := userSSHConfig().RewriteURL(remoteURL); ok {
+			remoteURL = resolved
+			reportRow(w, "  rewritten", "%s (ssh config HostName)", remoteURL)
+		}
+		if webURL := convertToWebURL(remoteURL); webURL != "" {
+			reportRow(w, "  web URL", "%s", webURL)
+		} else {
+			reportRow(w, "  web URL", "none: unsupported remote URL format")
+		}
+		reportRow(w, "  provider", "%s", getHostingService(remoteURL).Name)
+	}
+
+	fmt.Fprintln(w, "Settings")
+	source := func(key, userValue, projectValue string) string {
+		if fast.commonDir != projectCommonDir {
+			projectValue = ""
+		}
+		return settingSource(fast.config, key, userValue, projectValue)
+	}
+	reportRow(w, "remote", "%s (%s)", settings.Remote, remoteSource(fast.commonDir, fast.config))
+
+	var remoteURL, webURL string
+	resolved, err := resolveRepositoryURLs(repo, fast, settings)
+	if err == nil {
+		remoteURL, webURL = resolved.target.RemoteURL, resolved.target.HomeURL
+	}
+	if settings.Provider != "" {
+		reportRow(w, "provider", "%s (%s)", settings.Provider, source("provider", Provider, projectSettings.Provider))
+	} else if err == nil {
+		reportRow(w, "provider", "%s (detected from the remote URL)", getHostingService(remoteURL).Name)
+	}
+	if settings.DefaultBranch != "" {
+		reportRow(w, "default branch", "%s (%s)", settings.DefaultBranch, source("defaultBranch", DefaultBranch, projectSettings.DefaultBranch))
+	} else {
+		reportRow(w, "default branch", "main or master (built-in)")
+	}
+	if err != nil {
+		reportRow(w, "web URL", "none: %v", err)
+		return "", settings
+	}
+	if settings.WebURL != "" {
+		reportRow(w, "web URL", "%s (%s)", webURL, source("webURL", "", projectSettings.WebURL))
+	} else {
+		reportRow(w, "web URL", "%s", webURL)
+	}
+	return webURL, settings
+}
+
+// discoveryDescription describes how the repository in the current working
+// directory is found.
+func discoveryDescription() string {
+	d := discovery()
+	if d.GitDir != "" || d.WorkTree != "" {
+		return fmt.Sprintf("from the environment (GIT_DIR=%q, GIT_WORK_TREE=%q)", d.GitDir, d.WorkTree)
+	}
+	gitDir, workTree, err := gitopen.FindGitDir(".", d, logger)
+	if err != nil {
+		return err.Error()
+	}
+	if workTree == "" {
+		return "bare repository " + gitDir
+	}
+	dotGit := filepath.Join(workTree, git.GitDirName)
+	if fi, err := os.Stat(dotGit); err == nil && !fi.IsDir() {
+		return "gitdir file " + dotGit
+	}
+	return ".git directory " + dotGit
+}
+
+// storageDescription describes how go-git opened repo: directly, or through
+// hiding the extensions in cfg it does not support.
+func storageDescription(repo *git.Repository, cfg *gitopen.Config) string {
+	if !gitopen.IgnoresExtensions(repo) {
+		return "go-git"
+	}
+	var extensions []string
+	for _, key := range cfg.Keys("extensions", "") {
+		value, _ := cfg.Get("extensions", "", key)
+		extensions = append(extensions, key+"="+value)
+	}
+	if len(extensions) == 0 {
+		return "go-git, ignoring [extensions]"
+	}
+	return "go-git, ignoring [extensions] " + strings.Join(extensions, ", ")
+}
+
+// headDescription describes what HEAD of repo points to.
+func headDescription(repo *git.Repository, fast *fastRepository) string {
+	head, err := repo.Head()
+	switch {
+	case err != nil:
+		if branch := gitopen.HeadBranch(fast.gitDir); branch != "" {
+			return "branch " + branch + " (no commits)"
+		}
+		return "unreadable: " + err.Error()
+	case head.Name().IsBranch():
+		return "branch " + head.Name().Short()
+	}
+	detached := "detached at " + head.Hash().String()[:7]
+	if tag, _ := gitopen.CommitTag(context.Background(), repo, head.Hash()); tag != "" {
+		detached += " (tag " + tag + ")"
+	}
+	return detached
+}
+
+// writeConfigReport writes the config files in use and the GIT_OPEN_
+// environment variables set.
+func writeConfigReport(w io.Writer) {
+	fmt.Fprintln(w, "Config")
+	if used := viper.ConfigFileUsed(); used == "" {
+		reportRow(w, "user", "none")
+	} else if _, err := os.Stat(used); err != nil {
+		reportRow(w, "user", "%s (unreadable: %v)", used, err)
+	} else {
+		reportRow(w, "user", "%s", used)
+	}
+	if projectConfigFile != "" {
+		reportRow(w, "project", "%s", projectConfigFile)
+	} else {
+		reportRow(w, "project", "none")
+	}
+	for _, problem := range projectConfigProblems {
+		reportRow(w, "problem", "%s", problem)
+	}
+	for _, env := range os.Environ() {
+		if name, value, _ := strings.Cut(env, "="); strings.HasPrefix(name, "GIT_OPEN_") {
+			reportRow(w, name, "%s", value)
+		}
+	}
+}
+
+// writeBrowserReport writes the launchers that would open webURL, in the order
+// openURLInBrowser tries them, and which of them exist.
+func writeBrowserReport(w io.Writer, webURL string, settings repoSettings) {
+	fmt.Fprintln(w, "Browser")
+	platform := getPlatform()
+	if platform == "linux" && isWSL() {
+		platform += " (WSL)"
+	}
+	reportRow(w, "platform", "%s", platform)
+
+	var uses string
+	command, source := settings.Browser, "git config "+gitopen.ConfigSection+".browser"
+	if command == "" {
+		command, source = browserCommandForURL(webURL), "browser config"
+		if command != strings.TrimSpace(BrowserCommand) {
+			source = "browsers config"
+		}
+	}
+	if command != "" {
+		name, _, err := expandBrowserCommand(command, webURL)
+		if err != nil {
+			reportRow(w, "command", "%s (%s, invalid: %v)", command, source, err)
+		} else {
+			status := programStatus(name)
+			reportRow(w, "command", "%s (%s, %s)", command, source, status)
+			if status != notFound {
+				uses = command
+			}
+		}
+	}
+	for _, entry := range strings.Split(os.Getenv("BROWSER"), string(os.PathListSeparator)) {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		name, _, err := expandCommandTemplate(entry, browserEnvPlaceholder, webURL)
+		if err != nil {
+			reportRow(w, "BROWSER", "%s (invalid: %v)", entry, err)
+			continue
+		}
+		status := programStatus(name)
+		reportRow(w, "BROWSER", "%s (%s)", entry, status)
+		if uses == "" && status != notFound {
+			uses = entry
+		}
+	}
+
+	headless := isHeadless(getPlatform())
+	if headless {
+		reportRow(w, "headless", "yes")
+	} else {
+		reportRow(w, "headless", "no")
+	}
+	for _, name := range platformLauncherPrograms(getPlatform()) {
+		status := programStatus(name)
+		reportRow(w, "launcher", "%s (%s)", name, status)
+		if uses == "" && !headless && status != notFound {
+			uses = name
+		}
+	}
+
+	switch {
+	case uses != "":
+		reportRow(w, "opens with", "%s", uses)
+	case headless:
+		reportRow(w, "opens with", "nothing: the URL is printed, as there is no display")
+	default:
+		reportRow(w, "opens with", "nothing: no launcher was found")
+	}
+}
+
+// notFound is the status of programs missing from PATH.
+const notFound = "not found"
+
+// programStatus returns the path name is found at on PATH, or notFound.
+func programStatus(name string) string {
+	path, err := lookPath(name)
+	if err != nil {
+		return notFound
+	}
+	return path
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+}
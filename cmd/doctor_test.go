@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"bytes"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/zhaochunqi/git-open/internal/testhelper"
+	"github.com/zhaochunqi/git-open/pkg/gitopen"
+)
+
+func Test_doctorCmd(t *testing.T) {
+	dir, cleanup := testhelper.SetupTestRepo(t, "gh:owner/repo", "feature")
+	defer cleanup()
+	appendGitConfig(t, dir, `[url "git@github.com:"]
+	insteadOf = gh:
+[remote "upstream"]
+	url = https://example.com/upstream/repo.git
+[git-open]
+	provider = github
+`)
+	withYAMLSettings(t, "", "", "")
+	withProjectConfig(t, dir, "defaultBranch: develop\nbrowser: curl\n", "browser: firefox {url}\nremote: origin\n")
+
+	originalGetPlatform, originalLookPath, originalReadOSRelease := getPlatform, lookPath, readOSRelease
+	t.Cleanup(func() {
+		getPlatform, lookPath, readOSRelease = originalGetPlatform, originalLookPath, originalReadOSRelease
+	})
+	getPlatform = func() string { return "linux" }
+	readOSRelease = func() (string, error) { return "6.1.0-generic", nil }
+	lookPath = func(name string) (string, error) {
+		if name == "xdg-open" {
+			return "/usr/bin/xdg-open", nil
+		}
+		return "", exec.ErrNotFound
+	}
+	t.Setenv("SSH_CONNECTION", "")
+	t.Setenv("DISPLAY", ":0")
+	t.Setenv("BROWSER", "")
+
+	buf := new(bytes.Buffer)
+	cmd := &cobra.Command{}
+	cmd.SetOut(buf)
+	if err := doctorCmd.RunE(cmd, nil); err != nil {
+		t.Fatalf("doctorCmd.RunE() error = %v", err)
+	}
+
+	// Compare rows with the alignment padding collapsed
+	rows := make(map[string]bool)
+	for _, line := range strings.Split(buf.String(), "\n") {
+		rows[strings.Join(strings.Fields(line), " ")] = true
+	}
+	for _, want := range []string{
+		"found .git directory " + filepath.Join(dir, ".git"),
+		"HEAD branch feature",
+		"origin (opened) gh:owner/repo",
+		"rewritten git@github.com:owner/repo (url.<base>.insteadOf)",
+		"web URL https://github.com/owner/repo",
+		"upstream https://example.com/upstream/repo.git",
+		"provider unknown",
+		"remote origin (user config)",
+		"provider github (git config git-open.provider)",
+		"default branch develop (project config)",
+		"project " + filepath.Join(dir, projectConfigName),
+		`problem "browser" ignored: it can only be set in the user config`,
+		"command firefox {url} (browser config, not found)",
+		"headless no",
+		"launcher xdg-open (/usr/bin/xdg-open)",
+		"launcher gio (not found)",
+		"opens with xdg-open",
+	} {
+		if !rows[want] {
+			t.Errorf("doctor output lacks %q:\n%s", want, buf.String())
+		}
+	}
+}
+
+func Test_settingSource(t *testing.T) {
+	cfg, err := gitopen.ParseConfig("[git-open]\n\tprovider = gitlab\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		key          string
+		userValue    string
+		projectValue string
+		want         string
+	}{
+		{"git config beats the YAML configs", "provider", "github", "bitbucket", "git config git-open.provider"},
+		{"user config beats the project config", "remote", "upstream", "fork", "user config"},
+		{"project config", "remote", "", "fork", "project config"},
+		{"default", "defaultBranch", "", "", "default"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := settingSource(cfg, tt.key, tt.userValue, tt.projectValue); got != tt.want {
+				t.Errorf("settingSource() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"runtime"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// eachResult is the resolved web URL of one repository found by the each
+// command, or the error resolving it.
+type eachResult struct {
+	Path string `json:"path"`
+	*Target
+	Error string `json:"error,omitempty"`
+}
+
+// eachCmd represents the each command
+var eachCmd = &cobra.Command{
+	Use:   "each <dir>...",
+	Short: "List the web URLs of every repository under the given directories",
+	Long: `Find every Git repository under the given directories and print its current
+branch and web URL, as a table or as JSON. Repositories are resolved in parallel.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		maxDepth, _ := cmd.Flags().GetInt("max-depth")
+		jobs, _ := cmd.Flags().GetInt("jobs")
+		asJSON, _ := cmd.Flags().GetBool("json")
+
+		var paths []string
+		for _, dir := range args {
+			repos, err := findRepositories(dir, maxDepth)
+			if err != nil {
+				return fmt.Errorf("error searching %s: %w", dir, err)
+			}
+			paths = append(paths, repos...)
+		}
+
+		results := resolveRepositories(paths, jobs)
+		if asJSON {
+			return writeEachJSON(cmd.OutOrStdout(), results)
+		}
+		return writeEachTable(cmd.OutOrStdout(), results)
+	},
+}
+
+// resolveRepositories resolves the repositories at paths with at most jobs
+// running concurrently, returning the results in the order of paths.
+func resolveRepositories(paths []string, jobs int) []eachResult {
+	if jobs < 1 {
+		jobs = 1
+	}
+
+	results := make([]eachResult, len(paths))
+	sem := make(chan struct{}, jobs)
+	var wg sync.WaitGroup
+	for i, path := range paths {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = resolveRepositoryAt(path)
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// resolveRepositoryAt resolves the web URL of the repository at path.
+func resolveRepositoryAt(path string) eachResult {
+	result := eachResult{Path: path}
+	repo, err := openRepository(path)
+	if err != nil {
+		result.Error = fmt.Sprintf("error getting git directory: %v", err)
+		return result
+	}
+	resolved, err := resolveRepositoryWebURL(repo)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Target = resolved.homeTarget()
+	return result
+}
+
+func writeEachJSON(w io.Writer, results []eachResult) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(results)
+}
+
+func writeEachTable(w io.Writer, results []eachResult) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "PATH\tBRANCH\tWEB URL")
+	for _, result := range results {
+		if result.Error != "" {
+			fmt.Fprintf(tw, "%s\t-\terror: %s\n", result.Path, result.Error)
+			continue
+		}
+		branch := result.Branch
+		if branch == "" {
+			branch = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", result.Path, branch, result.WebURL)
+	}
+	return tw.Flush()
+}
+
+func init() {
+	eachCmd.Flags().Bool("json", false, "Print the results as JSON.")
+	eachCmd.Flags().IntP("jobs", "j", runtime.NumCPU(), "Number of repositories to resolve in parallel.")
+	eachCmd.Flags().Int("max-depth", maxWorkspaceDepth, "How many directory levels to search below each directory.")
+	rootCmd.AddCommand(eachCmd)
+}
@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/spf13/cobra"
+)
+
+func Test_resolveRepositories(t *testing.T) {
+	root := t.TempDir()
+	initRepoWithRemote(t, filepath.Join(root, "a"), "git@github.com:owner/a.git")
+	initRepoWithRemote(t, filepath.Join(root, "b"), "https://gitlab.com/group/b.git")
+	if _, err := git.PlainInit(filepath.Join(root, "no-remote"), false); err != nil {
+		t.Fatal(err)
+	}
+
+	paths := []string{filepath.Join(root, "a"), filepath.Join(root, "b"), filepath.Join(root, "no-remote")}
+	results := resolveRepositories(paths, 2)
+
+	if len(results) != 3 {
+		t.Fatalf("resolveRepositories() returned %d results, want 3", len(results))
+	}
+	for i, path := range paths {
+		if results[i].Path != path {
+			t.Errorf("results[%d].Path = %q, want %q", i, results[i].Path, path)
+		}
+	}
+	if results[0].Target == nil || results[0].WebURL != "https://github.com/owner/a" {
+		t.Errorf("results[0] = %+v, want web URL https://github.com/owner/a", results[0])
+	}
+	if results[1].Target == nil || results[1].Provider != "gitlab" {
+		t.Errorf("results[1] = %+v, want provider gitlab", results[1])
+	}
+	if results[2].Target != nil || !strings.Contains(results[2].Error, "remote") {
+		t.Errorf("results[2] = %+v, want a remote error", results[2])
+	}
+}
+
+func Test_eachCmd(t *testing.T) {
+	root := t.TempDir()
+	initRepoWithRemote(t, filepath.Join(root, "services", "a"), "git@github.com:owner/a.git")
+	initRepoWithRemote(t, filepath.Join(root, "b"), "https://github.com/owner/b.git")
+
+	origDir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(root); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(origDir) })
+
+	newCmd := func(asJSON bool) (*cobra.Command, *bytes.Buffer) {
+		buf := new(bytes.Buffer)
+		cmd := &cobra.Command{}
+		cmd.SetOut(buf)
+		cmd.Flags().Bool("json", asJSON, "")
+		cmd.Flags().Int("jobs", 4, "")
+		cmd.Flags().Int("max-depth", maxWorkspaceDepth, "")
+		return cmd, buf
+	}
+
+	t.Run("table", func(t *testing.T) {
+		cmd, buf := newCmd(false)
+		if err := eachCmd.RunE(cmd, []string{"."}); err != nil {
+			t.Fatalf("eachCmd.RunE() error = %v", err)
+		}
+		want := "PATH        BRANCH  WEB URL\n" +
+			"b           -       https://github.com/owner/b\n" +
+			filepath.Join("services", "a") + "  -       https://github.com/owner/a\n"
+		if got := buf.String(); got != want {
+			t.Errorf("each output = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		cmd, buf := newCmd(true)
+		if err := eachCmd.RunE(cmd, []string{"b"}); err != nil {
+			t.Fatalf("eachCmd.RunE() error = %v", err)
+		}
+		var got []map[string]any
+		if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+			t.Fatalf("each output is not JSON: %v\n%s", err, buf.String())
+		}
+		if len(got) != 1 || got[0]["path"] != "b" || got[0]["webURL"] != "https://github.com/owner/b" {
+			t.Errorf("each JSON = %v, want path b with web URL https://github.com/owner/b", got)
+		}
+	})
+
+	t.Run("missing directory", func(t *testing.T) {
+		cmd, _ := newCmd(false)
+		if err := eachCmd.RunE(cmd, []string{"missing"}); err == nil {
+			t.Error("eachCmd.RunE() expected error for missing directory, got nil")
+		}
+	})
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"errors"
+
+	"github.com/zhaochunqi/git-open/pkg/gitopen"
+)
+
+// The errors git-open exits with distinct codes for. Except for ErrBrowser,
+// they are those of the gitopen library.
+var (
+	ErrNotRepository  = gitopen.ErrNotRepository
+	ErrNoRemote       = gitopen.ErrNoRemote
+	ErrUnsupportedURL = gitopen.ErrUnsupportedURL
+	ErrRefNotFound    = gitopen.ErrRefNotFound
+	// ErrBrowser is returned when no browser could be opened.
+	ErrBrowser = errors.New("error opening URL in browser")
+)
+
+// exitCodes maps errors to the exit code and hint git-open exits with, so that
+// scripts can tell them apart. Other errors exit with 1.
+var exitCodes = []struct {
+	err  error
+	code int
+	hint string
+}{
+	{ErrNotRepository, 2, "run git-open inside a Git repository, or pass -C <path>"},
+	{ErrNoRemote, 3, `add a remote with "git remote add origin <url>", or pick another one with --remote`},
+	{ErrUnsupportedURL, 4, `set the web URL with "git config git-open.webURL <url>"`},
+	{ErrBrowser, 5, `set "browser" in the config or BROWSER, or pass --plain to print the URL`},
+	{ErrRefNotFound, 6, "check that the branch or commit exists; a new repository needs a first commit"},
+}
+
+// ExitCode returns the exit code for err: 0 for nil, a distinct code for the
+// errors above and 1 for any other error.
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	for _, e := range exitCodes {
+		if errors.Is(err, e.err) {
+			return e.code
+		}
+	}
+	return 1
+}
+
+// Hint returns advice on fixing err, or "" when there is none.
+func Hint(err error) string {
+	for _, e := range exitCodes {
+		if errors.Is(err, e.err) {
+			return e.hint
+		}
+	}
+	return ""
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/zhaochunqi/git-open/internal/testhelper"
+)
+
+func Test_ExitCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantCode int
+		wantHint bool
+	}{
+		{"nil", nil, 0, false},
+		{"other error", errors.New("boom"), 1, false},
+		{"not a repository", fmt.Errorf("error getting git directory: %w", ErrNotRepository), 2, true},
+		{"no remote", fmt.Errorf("error getting remote URL: %w: origin", ErrNoRemote), 3, true},
+		{"unsupported URL", fmt.Errorf("%w: foo", ErrUnsupportedURL), 4, true},
+		{"browser", fmt.Errorf("%w: %w", ErrBrowser, ErrMockBrowser), 5, true},
+		{"ref not found", fmt.Errorf("error getting HEAD: %w", ErrRefNotFound), 6, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExitCode(tt.err); got != tt.wantCode {
+				t.Errorf("ExitCode() = %d, want %d", got, tt.wantCode)
+			}
+			if got := Hint(tt.err); (got != "") != tt.wantHint {
+				t.Errorf("Hint() = %q, want a hint: %v", got, tt.wantHint)
+			}
+		})
+	}
+}
+
+func Test_resolveWebURL_Errors(t *testing.T) {
+	tests := []struct {
+		name      string
+		remoteURL string
+		want      error
+	}{
+		{"no remote", "", ErrNoRemote},
+		{"unsupported remote URL", "foo", ErrUnsupportedURL},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, cleanup := testhelper.SetupTestRepo(t, tt.remoteURL, "main")
+			defer cleanup()
+			withYAMLSettings(t, "", "", "")
+
+			if _, err := resolveWebURL(); !errors.Is(err, tt.want) {
+				t.Errorf("resolveWebURL() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	t.Run("not a repository", func(t *testing.T) {
+		original, err := os.Getwd()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.Chdir(original)
+		if err := os.Chdir(t.TempDir()); err != nil {
+			t.Fatal(err)
+		}
+		t.Setenv("GIT_CEILING_DIRECTORIES", os.TempDir())
+
+		if _, err := resolveWebURL(); !errors.Is(err, ErrNotRepository) {
+			t.Errorf("resolveWebURL() error = %v, want %v", err, ErrNotRepository)
+		}
+	})
+}
+
+func Test_showWebURL_BrowserError(t *testing.T) {
+	original := OpenURLInBrowser
+	t.Cleanup(func() { OpenURLInBrowser = original })
+	OpenURLInBrowser = func(url string) error { return ErrMockBrowser }
+
+	err := showWebURL(&cobra.Command{}, "https://github.com/owner/repo", repoSettings{})
+	if !errors.Is(err, ErrBrowser) || !errors.Is(err, ErrMockBrowser) {
+		t.Errorf("showWebURL() error = %v, want it to wrap %v and the launcher's error", err, ErrBrowser)
+	}
+}
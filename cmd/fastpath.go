@@ -0,0 +1,201 @@
+package cmd
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/zhaochunqi/git-open/pkg/gitopen"
+)
+
+// fastResolveWebURLFunc resolves the remote URL, web URL and branch of the
+// repository in the current working directory by reading its config and HEAD
+// directly, which is much cheaper than opening it with go-git. ok is false when
+// the fast path cannot answer and go-git must be used instead, in which case
+// the resolution is nil or only holds the repository read. It can be replaced
+// for testing.
+var fastResolveWebURLFunc = fastResolveWebURL
+
+// fastResolution is what the fast path resolves for a repository.
+type fastResolution struct {
+	remoteURL string
+	webURL    string
+	// branch is empty for a detached HEAD or a branch without commits.
+	branch string
+	// pushed is whether branch points to the same commit as its
+	// remote-tracking branch.
+	pushed bool
+	// detached is whether HEAD points to a commit rather than a branch.
+	detached bool
+	settings repoSettings
+	// repo is the repository read, which go-git reuses when the fast path
+	// cannot answer.
+	repo *fastRepository
+}
+
+// fastRepository is the part of a repository read by the fast path.
+type fastRepository struct {
+	// gitDir is the git directory of the worktree, holding HEAD.
+	gitDir string
+	// commonDir is the git directory shared by all worktrees, holding the
+	// config and refs.
+	commonDir string
+	// workTree is the working tree, empty for a bare repository.
+	workTree string
+	bare     bool
+	config   *gitopen.Config
+}
+
+// startRepository is the repository initConfig found in the directory
+// git-open runs in, which is not searched for again.
+var startRepository struct {
+	dir, gitDir, workTree string
+}
+
+// findStartRepository finds the repository containing dir, the directory
+// git-open runs in, and remembers it for findGitDir.
+func findStartRepository(dir string) (gitDir, workTree string, err error) {
+	startRepository.dir, startRepository.gitDir, startRepository.workTree = "", "", ""
+	gitDir, workTree, err = gitopen.FindGitDir(dir, discovery(), logger)
+	if err != nil {
+		return "", "", err
+	}
+	if abs, err := filepath.Abs(dir); err == nil {
+		startRepository.dir, startRepository.gitDir, startRepository.workTree = abs, gitDir, workTree
+	}
+	return gitDir, workTree, nil
+}
+
+// findGitDir is gitopen.FindGitDir, reusing the repository findStartRepository
+// found when path is the directory it was found from.
+func findGitDir(path string) (gitDir, workTree string, err error) {
+	if abs, err := filepath.Abs(path); err == nil && startRepository.dir != "" && abs == startRepository.dir {
+		return startRepository.gitDir, startRepository.workTree, nil
+	}
+	return gitopen.FindGitDir(path, discovery(), logger)
+}
+
+// openFastRepository locates the repository containing path and reads its
+// config.
+func openFastRepository(path string) (*fastRepository, error) {
+	gitDir, workTree, err := findGitDir(path)
+	if err != nil {
+		return nil, err
+	}
+	repo, err := newFastRepository(gitDir, workTree == "")
+	if err != nil {
+		return nil, err
+	}
+	repo.workTree = workTree
+	return repo, nil
+}
+
+// newFastRepository reads the effective config of the repository whose git
+// directory is gitDir.
+func newFastRepository(gitDir string, bare bool) (*fastRepository, error) {
+	commonDir, err := gitopen.CommonGitDir(gitDir)
+	if err != nil {
+		return nil, err
+	}
+	repo := &fastRepository{gitDir: gitDir, commonDir: commonDir, bare: bare}
+
+	config, err := gitopen.ReadConfig(repo.gitDir, repo.commonDir, logger)
+	if err != nil {
+		return nil, err
+	}
+	repo.config = config
+	return repo, nil
+}
+
+// branch returns the branch HEAD points to. Like getBranchNameFunc, a branch
+// without commits only counts in a bare repository.
+func (r *fastRepository) branch() (string, bool) {
+	branch := gitopen.HeadBranch(r.gitDir)
+	if branch == "" || (!r.bare && !r.refExists("refs/heads/"+branch)) {
+		return "", false
+	}
+	return branch, true
+}
+
+// refExists reports whether ref is a loose or packed ref.
+func (r *fastRepository) refExists(ref string) bool {
+	_, ok := r.refHash(ref)
+	return ok
+}
+
+// refHash returns the object name a loose or packed ref points to. Symbolic
+// refs are not followed.
+func (r *fastRepository) refHash(ref string) (string, bool) {
+	if b, err := os.ReadFile(filepath.Join(r.commonDir, filepath.FromSlash(ref))); err == nil {
+		return strings.TrimSpace(string(b)), true
+	}
+
+	f, err := os.Open(filepath.Join(r.commonDir, "packed-refs"))
+	if err != nil {
+		return "", false
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if hash, name, ok := strings.Cut(scanner.Text(), " "); ok && name == ref {
+			return hash, true
+		}
+	}
+	return "", false
+}
+
+// pushed reports whether branch points to the same commit as its
+// remote-tracking branch of remote. Anything else needs go-git to tell.
+func (r *fastRepository) pushed(remote, branch string) bool {
+	local, ok := r.refHash("refs/heads/" + branch)
+	if !ok {
+		return false
+	}
+	tracking, ok := r.refHash("refs/remotes/" + remote + "/" + branch)
+	return ok && tracking == local
+}
+
+// fastResolveWebURL is the default fastResolveWebURLFunc.
+func fastResolveWebURL() (*fastResolution, bool) {
+	// Leave the environment overrides and anything unusual to go-git
+	if d := discovery(); d.GitDir != "" || d.WorkTree != "" {
+		return nil, false
+	}
+
+	repo, err := openFastRepository(".")
+	if err != nil {
+		return nil, false
+	}
+	unresolved := &fastResolution{repo: repo}
+	if storage, _ := repo.config.Get("extensions", "", "refstorage"); storage != "" && storage != "files" {
+		return unresolved, false
+	}
+	settings, err := repo.settings()
+	if err != nil {
+		return unresolved, false
+	}
+
+	remoteURL, ok := repo.config.RemoteURL(settings.Remote)
+	if !ok {
+		logger.Debug("remote has no URL", "remote", settings.Remote)
+		return unresolved, false
+	}
+	logger.Debug("selected remote", "remote", settings.Remote, "source", remoteSource(repo.commonDir, repo.config), "url", remoteURL)
+	remoteURL = resolveSSHAlias(remoteURL)
+	webURL := settings.WebURL
+	if webURL == "" {
+		webURL = convertToWebURL(remoteURL)
+	}
+	if webURL == "" {
+		return unresolved, false
+	}
+	resolved := &fastResolution{remoteURL: remoteURL, webURL: webURL, settings: settings, repo: repo}
+	resolved.detached = gitopen.HeadBranch(repo.gitDir) == ""
+	if branch, ok := repo.branch(); ok {
+		resolved.branch = branch
+		resolved.pushed = repo.pushed(settings.Remote, branch)
+	}
+	return resolved, true
+}
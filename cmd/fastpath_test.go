@@ -0,0 +1,201 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/zhaochunqi/git-open/internal/testhelper"
+)
+
+// withoutFastPath makes commands resolve repositories with go-git, for tests
+// that mock the go-git seams.
+func withoutFastPath(t *testing.T) {
+	t.Helper()
+	original := fastResolveWebURLFunc
+	fastResolveWebURLFunc = func() (*fastResolution, bool) { return nil, false }
+	t.Cleanup(func() { fastResolveWebURLFunc = original })
+}
+
+func Test_fastResolveWebURL(t *testing.T) {
+	tests := []struct {
+		name       string
+		remoteURL  string
+		branch     string
+		wantWebURL string
+		wantBranch string
+	}{
+		{"main branch", "git@github.com:owner/repo.git", "main", "https://github.com/owner/repo", "main"},
+		{"feature branch", "https://gitlab.com/group/repo.git", "feature", "https://gitlab.com/group/repo", "feature"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, cleanup := testhelper.SetupTestRepo(t, tt.remoteURL, tt.branch)
+			defer cleanup()
+
+			got, ok := fastResolveWebURL()
+			if !ok {
+				t.Fatal("fastResolveWebURL() ok = false, want true")
+			}
+			if got.remoteURL != tt.remoteURL || got.webURL != tt.wantWebURL || got.branch != tt.wantBranch {
+				t.Errorf("fastResolveWebURL() = %q, %q, %q, want %q, %q, %q", got.remoteURL, got.webURL, got.branch, tt.remoteURL, tt.wantWebURL, tt.wantBranch)
+			}
+		})
+	}
+}
+
+func Test_fastResolveWebURL_Worktree(t *testing.T) {
+	_, cleanup := testhelper.SetupTestWorktree(t, "https://github.com/owner/repo.git", "feature-branch")
+	defer cleanup()
+
+	got, ok := fastResolveWebURL()
+	if !ok || got.webURL != "https://github.com/owner/repo" || got.branch != "feature-branch" {
+		t.Errorf("fastResolveWebURL() = %+v, %v, want the main repository's remote and the worktree's branch", got, ok)
+	}
+}
+
+func Test_fastResolveWebURL_Includes(t *testing.T) {
+	dir, cleanup := testhelper.SetupTestRepo(t, "", "main")
+	defer cleanup()
+
+	// The remote is only defined in a conditionally included file
+	include := filepath.Join(dir, "remote.inc")
+	if err := os.WriteFile(include, []byte("[remote \"origin\"]\n\turl = git@github.com:owner/included.git\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.OpenFile(filepath.Join(dir, ".git", "config"), os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = f.WriteString("[includeIf \"gitdir:" + filepath.ToSlash(dir) + "/\"]\n\tpath = ../remote.inc\n")
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, ok := fastResolveWebURL()
+	if !ok || got.webURL != "https://github.com/owner/included" {
+		t.Errorf("fastResolveWebURL() = %+v, %v, want the included remote", got, ok)
+	}
+}
+
+func Test_fastResolveWebURL_Fallback(t *testing.T) {
+	t.Run("no origin", func(t *testing.T) {
+		_, cleanup := testhelper.SetupTestRepo(t, "", "main")
+		defer cleanup()
+		if _, ok := fastResolveWebURL(); ok {
+			t.Error("fastResolveWebURL() ok = true without a remote, want false")
+		}
+	})
+
+	t.Run("GIT_DIR", func(t *testing.T) {
+		dir, cleanup := testhelper.SetupTestRepo(t, "https://github.com/owner/repo.git", "main")
+		defer cleanup()
+		t.Setenv("GIT_DIR", filepath.Join(dir, ".git"))
+		if _, ok := fastResolveWebURL(); ok {
+			t.Error("fastResolveWebURL() ok = true with GIT_DIR set, want false")
+		}
+	})
+
+	t.Run("unsupported remote", func(t *testing.T) {
+		_, cleanup := testhelper.SetupTestRepo(t, "invalid-remote", "main")
+		defer cleanup()
+		if _, ok := fastResolveWebURL(); ok {
+			t.Error("fastResolveWebURL() ok = true for an unsupported remote, want false")
+		}
+	})
+}
+
+func Test_fastRepository_branch(t *testing.T) {
+	t.Run("unborn branch", func(t *testing.T) {
+		_, cleanup := testhelper.SetupTestRepoWithoutCommit(t, "https://github.com/owner/repo.git", "feature")
+		defer cleanup()
+
+		repo, err := openFastRepository(".")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if branch, ok := repo.branch(); ok {
+			t.Errorf("branch() = %q, want none for a branch without commits", branch)
+		}
+	})
+
+	t.Run("packed ref", func(t *testing.T) {
+		if _, err := exec.LookPath("git"); err != nil {
+			t.Skip("git not installed")
+		}
+		dir, cleanup := testhelper.SetupTestRepo(t, "https://github.com/owner/repo.git", "feature")
+		defer cleanup()
+		cmd := exec.Command("git", "pack-refs", "--all")
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git pack-refs failed: %v\n%s", err, out)
+		}
+
+		repo, err := openFastRepository(".")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if branch, ok := repo.branch(); !ok || branch != "feature" {
+			t.Errorf("branch() = %q, %v, want feature", branch, ok)
+		}
+	})
+}
+
+func Test_rootCmd_FastPathMatchesGoGit(t *testing.T) {
+	_, cleanup := testhelper.SetupTestRepo(t, "git@github.com:owner/repo.git", "feature")
+	defer cleanup()
+
+	run := func() string {
+		buf := new(bytes.Buffer)
+		cmd := &cobra.Command{}
+		cmd.SetOut(buf)
+		cmd.Flags().Bool("plain", true, "")
+		if err := rootCmd.RunE(cmd, []string{}); err != nil {
+			t.Fatalf("rootCmd.RunE() error = %v", err)
+		}
+		return buf.String()
+	}
+
+	fast := run()
+	withoutFastPath(t)
+	if slow := run(); fast != slow {
+		t.Errorf("fast path output = %q, go-git output = %q", fast, slow)
+	}
+	if want := "Web URL: https://github.com/owner/repo/tree/feature\n"; fast != want {
+		t.Errorf("rootCmd output = %q, want %q", fast, want)
+	}
+}
+
+// BenchmarkResolveWebURL compares resolving the remote, web URL and branch of
+// a repository with go-git against the fast path.
+func BenchmarkResolveWebURL(b *testing.B) {
+	_, cleanup := testhelper.SetupTestRepo(b, "git@github.com:zhaochunqi/git-open.git", "feature")
+	defer cleanup()
+
+	b.Run("go-git", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			resolved, err := resolveWebURL()
+			if err != nil {
+				b.Fatal(err)
+			}
+			if _, err := getBranchName(resolved.repo); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("fast path", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, ok := fastResolveWebURL(); !ok {
+				b.Fatal("fast path failed")
+			}
+		}
+	})
+}
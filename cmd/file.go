@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/spf13/cobra"
+)
+
+// fileCmd represents the file command
+var fileCmd = &cobra.Command{
+	Use:   "file [path[:line]]",
+	Short: "Open a file of the repository",
+	Long: `Open the web page of a file or directory of the Git repository in the current
+working directory at the current branch (or commit, on a detached HEAD). A
+":line" suffix, as printed by resolve, selects a line. Without a path, the
+tracked files are offered in a fuzzy-filterable picker.
+
+A warning is printed when the branch or commit has not been pushed, since the
+link would not work for anyone else.`,
+	Args: cobra.MaximumNArgs(1),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return completeTrackedFiles(toComplete), cobra.ShellCompDirectiveNoFileComp
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		resolved, err := resolveWebURL()
+		if err != nil {
+			return err
+		}
+		repo, settings, remoteURL, webURL := resolved.repo, resolved.settings, resolved.target.RemoteURL, resolved.target.HomeURL
+		wt, err := repo.Worktree()
+		if err != nil {
+			return fmt.Errorf("error getting working tree: %w", err)
+		}
+		branchName, _ := getBranchName(repo)
+		ref, err := linkRef(cmd, repo, settings.Remote, branchName)
+		if err != nil {
+			return err
+		}
+		provider := settings.hostingService(remoteURL)
+
+		if len(args) == 0 {
+			files, err := trackedFiles(repo)
+			if err != nil {
+				return err
+			}
+			targets := make([]pickerTarget, len(files))
+			for i, file := range files {
+				targets[i] = pickerTarget{file, provider.FileURL(webURL, ref, file, false, 0)}
+			}
+			picked, err := pickTargetFunc(cmd.InOrStdin(), cmd.OutOrStdout(), targets)
+			if err != nil {
+				return err
+			}
+			return showWebURL(cmd, picked.URL, settings)
+		}
+
+		path, line := splitPathLine(args[0])
+		relPath, isDir, err := repositoryPath(wt.Filesystem.Root(), path)
+		if err != nil {
+			return err
+		}
+		return showWebURL(cmd, provider.FileURL(webURL, ref, relPath, isDir, line), settings)
+	},
+}
+
+// trackedFiles returns the paths of the files in the index of repo, in order.
+func trackedFiles(repo *git.Repository) ([]string, error) {
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return nil, fmt.Errorf("error reading index: %w", err)
+	}
+	files := make([]string, len(idx.Entries))
+	for i, entry := range idx.Entries {
+		files[i] = entry.Name
+	}
+	return files, nil
+}
+
+// splitPathLine splits a "path:line" argument. Without a numeric suffix the
+// whole argument is the path and line is 0.
+func splitPathLine(arg string) (string, int) {
+	if i := strings.LastIndex(arg, ":"); i > 0 {
+		if line, err := strconv.Atoi(arg[i+1:]); err == nil && line > 0 {
+			return arg[:i], line
+		}
+	}
+	return arg, 0
+}
+
+// repositoryPath returns path, relative to the current working directory, as
+// a slash-separated path relative to the working tree root, and whether it is
+// a directory.
+func repositoryPath(root, path string) (string, bool, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", false, err
+	}
+	fi, err := os.Stat(abs)
+	if err != nil {
+		return "", false, err
+	}
+
+	// Compare real paths, since the working tree root may be reached through
+	// a symlink such as macOS's /var -> /private/var
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		abs = resolved
+	}
+	if resolved, err := filepath.EvalSymlinks(root); err == nil {
+		root = resolved
+	}
+	rel, err := filepath.Rel(root, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false, errors.New(path + " is outside the repository")
+	}
+	if rel == "." {
+		rel = ""
+	}
+	return filepath.ToSlash(rel), fi.IsDir(), nil
+}
+
+func init() {
+	fileCmd.Flags().BoolP("plain", "p", false, "Just print the web url without opening.")
+	addPushFlags(fileCmd)
+	rootCmd.AddCommand(fileCmd)
+}
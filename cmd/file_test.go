@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/zhaochunqi/git-open/internal/testhelper"
+)
+
+func Test_splitPathLine(t *testing.T) {
+	tests := []struct {
+		arg      string
+		wantPath string
+		wantLine int
+	}{
+		{"cmd/git.go", "cmd/git.go", 0},
+		{"cmd/git.go:10", "cmd/git.go", 10},
+		{"cmd/git.go:0", "cmd/git.go:0", 0},
+		{"notes:todo", "notes:todo", 0},
+		{"C:\\repo\\main.go:3", "C:\\repo\\main.go", 3},
+	}
+	for _, tt := range tests {
+		if path, line := splitPathLine(tt.arg); path != tt.wantPath || line != tt.wantLine {
+			t.Errorf("splitPathLine(%q) = %q, %d, want %q, %d", tt.arg, path, line, tt.wantPath, tt.wantLine)
+		}
+	}
+}
+
+func Test_fileCmd(t *testing.T) {
+	dir, cleanup := testhelper.SetupTestRepo(t, "git@github.com:owner/repo.git", "feature")
+	defer cleanup()
+
+	if err := os.MkdirAll(filepath.Join(dir, "docs"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "docs", "guide.md"), []byte("# Guide\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	repo, err := getCurrentGitDirectory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.Add("docs/guide.md"); err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(args ...string) (string, error) {
+		buf := new(bytes.Buffer)
+		cmd := &cobra.Command{}
+		cmd.SetOut(buf)
+		cmd.Flags().Bool("plain", true, "")
+		err := fileCmd.RunE(cmd, args)
+		return buf.String(), err
+	}
+
+	tests := []struct {
+		name string
+		dir  string
+		arg  string
+		want string
+	}{
+		{"file and line", ".", "test.txt:3", "https://github.com/owner/repo/blob/feature/test.txt#L3"},
+		{"directory", ".", "docs", "https://github.com/owner/repo/tree/feature/docs"},
+		{"relative to the working directory", "docs", "guide.md", "https://github.com/owner/repo/blob/feature/docs/guide.md"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := os.Chdir(filepath.Join(dir, tt.dir)); err != nil {
+				t.Fatal(err)
+			}
+			defer os.Chdir(dir)
+
+			got, err := run(tt.arg)
+			if err != nil {
+				t.Fatalf("fileCmd.RunE() error = %v", err)
+			}
+			if want := "Web URL: " + tt.want + "\n"; got != want {
+				t.Errorf("fileCmd output = %q, want %q", got, want)
+			}
+		})
+	}
+
+	t.Run("outside the repository", func(t *testing.T) {
+		outside := filepath.Join(t.TempDir(), "outside.txt")
+		if err := os.WriteFile(outside, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := run(outside); err == nil {
+			t.Error("fileCmd.RunE() expected error for a path outside the repository, got nil")
+		}
+	})
+
+	t.Run("picker", func(t *testing.T) {
+		original := pickTargetFunc
+		t.Cleanup(func() { pickTargetFunc = original })
+		var labels []string
+		pickTargetFunc = func(in io.Reader, out io.Writer, targets []pickerTarget) (pickerTarget, error) {
+			for _, target := range targets {
+				labels = append(labels, target.Label)
+			}
+			return targets[0], nil
+		}
+
+		got, err := run()
+		if err != nil {
+			t.Fatalf("fileCmd.RunE() error = %v", err)
+		}
+		if want := []string{"docs/guide.md", "test.txt"}; !reflect.DeepEqual(labels, want) {
+			t.Errorf("picker targets = %q, want the tracked files %q", labels, want)
+		}
+		if want := "Web URL: https://github.com/owner/repo/blob/feature/docs/guide.md\n"; got != want {
+			t.Errorf("fileCmd output = %q, want %q", got, want)
+		}
+	})
+}
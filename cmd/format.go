@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+)
+
+// Formats holds the named output formats from the "formats" config key,
+// mapping a name usable with --format to a Go template.
+var Formats map[string]string
+
+// formatFlagUsage is the help text of the --format flag.
+const formatFlagUsage = "Print the URL using a Go template or a named format (e.g. md, slack) instead of opening it. " +
+	"Fields: .Host .Owner .Repo .Branch .SHA .Detached .Tag .WebURL .RemoteURL .Provider"
+
+// builtinFormats are named formats available without any configuration.
+var builtinFormats = map[string]string{
+	"md":    "[{{.Repo}}]({{.WebURL}})",
+	"slack": "<{{.WebURL}}|{{.Repo}}>",
+}
+
+// formatTemplate returns the template for format, which is either the name of
+// a configured or built-in format, or a template itself. Names are matched
+// ignoring case, as the config keys they come from are lower-cased.
+func formatTemplate(format string) string {
+	name := strings.ToLower(format)
+	if tmpl, ok := Formats[name]; ok {
+		return tmpl
+	}
+	if tmpl, ok := builtinFormats[name]; ok {
+		return tmpl
+	}
+	return format
+}
+
+// printTarget renders target with format and writes it to w, followed by a
+// newline.
+func printTarget(w io.Writer, format string, target *Target) error {
+	tmpl, err := template.New("format").Option("missingkey=error").Parse(formatTemplate(format))
+	if err != nil {
+		return fmt.Errorf("invalid format %q: %w", format, err)
+	}
+
+	var b strings.Builder
+	if err := tmpl.Execute(&b, target); err != nil {
+		return fmt.Errorf("error executing format %q: %w", format, err)
+	}
+	_, err = fmt.Fprintln(w, b.String())
+	return err
+}
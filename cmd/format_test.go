@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/zhaochunqi/git-open/internal/testhelper"
+)
+
+func Test_printTarget(t *testing.T) {
+	originalFormats := Formats
+	t.Cleanup(func() { Formats = originalFormats })
+	Formats = map[string]string{
+		"org": "[[{{.WebURL}}][{{.Owner}}/{{.Repo}}]]",
+		"md":  "custom {{.Repo}}",
+	}
+
+	target := &Target{
+		Host:      "gitlab.com",
+		Owner:     "group/subgroup",
+		Repo:      "project",
+		Branch:    "feature",
+		SHA:       "0123456789abcdef0123456789abcdef01234567",
+		WebURL:    "https://gitlab.com/group/subgroup/project/-/tree/feature",
+		RemoteURL: "git@gitlab.com:group/subgroup/project.git",
+		Provider:  "gitlab",
+	}
+
+	tests := []struct {
+		name    string
+		format  string
+		want    string
+		wantErr bool
+	}{
+		{"inline template", "{{.Host}} {{.Owner}} {{.Repo}} {{.Branch}} {{.Provider}}", "gitlab.com group/subgroup project feature gitlab\n", false},
+		{"builtin slack", "slack", "<https://gitlab.com/group/subgroup/project/-/tree/feature|project>\n", false},
+		{"configured format", "org", "[[https://gitlab.com/group/subgroup/project/-/tree/feature][group/subgroup/project]]\n", false},
+		{"configured format overrides builtin", "md", "custom project\n", false},
+		{"template functions", `{{slice .SHA 0 7}}`, "0123456\n", false},
+		{"unknown field", "{{.Nope}}", "", true},
+		{"invalid template", "{{.Repo", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			err := printTarget(buf, tt.format, target)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("printTarget() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("printTarget() output = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_resolveWebURL_Target(t *testing.T) {
+	_, cleanup := testhelper.SetupTestRepo(t, "git@github.com:zhaochunqi/git-open.git", "feature")
+	defer cleanup()
+
+	resolved, err := resolveWebURL()
+	if err != nil {
+		t.Fatal(err)
+	}
+	head, err := resolved.repo.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := resolved.target
+	want := Target{
+		Host:      "github.com",
+		Owner:     "zhaochunqi",
+		Repo:      "git-open",
+		Branch:    "feature",
+		SHA:       head.Hash().String(),
+		WebURL:    "https://github.com/zhaochunqi/git-open/tree/feature",
+		HomeURL:   "https://github.com/zhaochunqi/git-open",
+		RemoteURL: "git@github.com:zhaochunqi/git-open.git",
+		Provider:  "github",
+	}
+	if *got != want {
+		t.Errorf("resolveWebURL() target = %+v, want %+v", *got, want)
+	}
+	if home := resolved.homeTarget(); home.WebURL != want.HomeURL || got.WebURL != want.WebURL {
+		t.Errorf("homeTarget().WebURL = %q, want %q without changing the target", home.WebURL, want.HomeURL)
+	}
+}
+
+func Test_rootCmd_FormatFlag(t *testing.T) {
+	_, cleanup := testhelper.SetupTestRepo(t, "https://github.com/zhaochunqi/git-open.git", "feature")
+	defer cleanup()
+
+	original := OpenURLInBrowser
+	t.Cleanup(func() { OpenURLInBrowser = original })
+	OpenURLInBrowser = func(url string) error {
+		t.Errorf("OpenURLInBrowser() called with %q, want the URL printed", url)
+		return nil
+	}
+
+	buf := new(bytes.Buffer)
+	cmd := &cobra.Command{}
+	cmd.SetOut(buf)
+	cmd.Flags().String("format", "", "")
+	if err := cmd.Flags().Set("format", "md"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := rootCmd.RunE(cmd, []string{}); err != nil {
+		t.Fatalf("rootCmd.RunE() error = %v", err)
+	}
+	if got, want := buf.String(), "[git-open](https://github.com/zhaochunqi/git-open/tree/feature)\n"; got != want {
+		t.Errorf("root command output = %q, want %q", got, want)
+	}
+}
+
+func Test_repoCmd_FormatFlag(t *testing.T) {
+	_, cleanup := testhelper.SetupTestRepo(t, "https://github.com/zhaochunqi/git-open.git", "feature")
+	defer cleanup()
+
+	buf := new(bytes.Buffer)
+	cmd := &cobra.Command{}
+	cmd.SetOut(buf)
+	cmd.Flags().String("format", "", "")
+	if err := cmd.Flags().Set("format", "{{.Owner}}/{{.Repo}}@{{.Branch}} {{.WebURL}}"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := repoCmd.RunE(cmd, []string{}); err != nil {
+		t.Fatalf("repoCmd.RunE() error = %v", err)
+	}
+	if got, want := buf.String(), "zhaochunqi/git-open@feature https://github.com/zhaochunqi/git-open\n"; got != want {
+		t.Errorf("repo command output = %q, want %q", got, want)
+	}
+}
+
+func Test_printTarget_ConfiguredFormatName(t *testing.T) {
+	dir, cleanup := testhelper.SetupTestRepo(t, "https://github.com/owner/repo.git", "main")
+	defer cleanup()
+
+	// The YAML config's keys are lower-cased when read
+	withProjectConfig(t, dir, "", `
+formats:
+  myFormat: "{{.Owner}}/{{.Repo}}"
+`)
+
+	buf := new(bytes.Buffer)
+	if err := printTarget(buf, "myFormat", &Target{Owner: "owner", Repo: "repo"}); err != nil {
+		t.Fatalf("printTarget() error = %v", err)
+	}
+	if got, want := buf.String(), "owner/repo\n"; got != want {
+		t.Errorf("printTarget() output = %q, want %q", got, want)
+	}
+}
@@ -1,294 +1,178 @@
 package cmd
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"io"
-	"net/url"
 	"os"
-	"path/filepath"
-	"regexp"
-	"strings"
 
-	"github.com/go-git/go-billy/v5"
-	"github.com/go-git/go-billy/v5/osfs"
 	"github.com/go-git/go-git/v5"
-	"github.com/go-git/go-git/v5/config"
-	"github.com/go-git/go-git/v5/plumbing/cache"
-	"github.com/go-git/go-git/v5/storage"
-	"github.com/go-git/go-git/v5/storage/filesystem"
-	"github.com/go-git/go-git/v5/storage/filesystem/dotgit"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/zhaochunqi/git-open/internal/homedir"
+	"github.com/zhaochunqi/git-open/pkg/gitopen"
 )
 
-// HostingService represents the type of Git hosting service.
-type HostingService int
-
-const (
-	Unknown HostingService = iota
-	GitHub
-	GitLab
-	Bitbucket
-	// Add other services as needed
-)
+// discovery returns how git finds repositories in git-open's environment:
+// GIT_DIR, GIT_WORK_TREE, GIT_CEILING_DIRECTORIES and
+// GIT_DISCOVERY_ACROSS_FILESYSTEM.
+func discovery() gitopen.Discovery {
+	return gitopen.DiscoveryFromEnv(os.Getenv)
+}
 
 // getCurrentGitDirectoryFunc is a variable that can be replaced for testing
 var getCurrentGitDirectoryFunc = func() (*git.Repository, error) {
-	repo, err := git.PlainOpenWithOptions(".", &git.PlainOpenOptions{
-		DetectDotGit:          true,
-		EnableDotGitCommonDir: true,
-	})
-	if err == nil {
-		return repo, nil
-	}
-	if !isRepositoryFormatError(err) {
-		return nil, err
-	}
-
-	// go-git rejects repositories that enable config extensions it does not
-	// know about (e.g. extensions.worktreeConfig), even though plain git
-	// handles them fine. Since git-open only reads the remote URL and the
-	// current branch, fall back to opening the repository while ignoring the
-	// extensions section of the config.
-	return openRepositoryToleratingExtensions(".")
+	return gitopen.Open(".", discovery(), logger)
 }
 
-// isRepositoryFormatError reports whether err is one of go-git's strict
-// repository format/extension validation errors.
-func isRepositoryFormatError(err error) bool {
-	return errors.Is(err, git.ErrUnsupportedExtensionRepositoryFormatVersion) ||
-		errors.Is(err, git.ErrUnknownExtension) ||
-		errors.Is(err, git.ErrUnsupportedRepositoryFormatVersion)
+// openRepository opens the Git repository containing path, walking up parent
+// directories like git does.
+func openRepository(path string) (*git.Repository, error) {
+	return gitopen.Discover(path, discovery(), logger)
 }
 
-// extensionTolerantStorer wraps a storage.Storer and hides the [extensions]
-// section of the repository config, so go-git's extension validation passes
-// for extensions it does not implement.
-type extensionTolerantStorer struct {
-	storage.Storer
+func getCurrentGitDirectory() (*git.Repository, error) {
+	return getCurrentGitDirectoryFunc()
 }
 
-func (s extensionTolerantStorer) Config() (*config.Config, error) {
-	cfg, err := s.Storer.Config()
-	if err != nil {
-		return nil, err
+// getRemoteURLFunc returns the URL of the named remote of repo, whose config
+// fast has read, or nil when it could not be read. It is a variable that can
+// be replaced for testing
+var getRemoteURLFunc = func(repo *git.Repository, fast *fastRepository, name string) (string, error) {
+	// Prefer the effective config, which unlike go-git's includes the global
+	// layers, included files and their insteadOf rules
+	var cfg *gitopen.Config
+	source := "go-git's config"
+	if fast != nil {
+		cfg, source = fast.config, remoteSource(fast.commonDir, fast.config)
 	}
-	if cfg != nil && cfg.Raw != nil {
-		cfg.Raw.RemoveSection("extensions")
-	}
-	return cfg, nil
-}
-
-// openRepositoryToleratingExtensions mirrors git.PlainOpenWithOptions with
-// DetectDotGit and EnableDotGitCommonDir enabled, but skips go-git's
-// extension validation by hiding the [extensions] config section.
-func openRepositoryToleratingExtensions(path string) (*git.Repository, error) {
-	dot, wt, err := dotGitFilesystems(path)
+	remoteURL, err := gitopen.RemoteURL(repo, cfg, name)
 	if err != nil {
-		return nil, err
-	}
-
-	if _, err := dot.Stat(""); err != nil {
-		if os.IsNotExist(err) {
-			return nil, git.ErrRepositoryNotExists
-		}
-		return nil, err
-	}
-
-	repositoryFs := dot
-	if common, err := dotGitCommonDirectory(dot); err != nil {
-		return nil, err
-	} else if common != nil {
-		repositoryFs = dotgit.NewRepositoryFilesystem(dot, common)
+		return "", err
 	}
-
-	s := filesystem.NewStorage(repositoryFs, cache.NewObjectLRUDefault())
-	return git.Open(extensionTolerantStorer{Storer: s}, wt)
+	logger.Debug("selected remote", "remote", name, "source", source, "url", remoteURL)
+	return remoteURL, nil
 }
 
-// dotGitFilesystems locates the .git filesystem and worktree filesystem for
-// path, walking up parent directories. It handles both .git directories and
-// .git files ("gitdir: <path>") used by worktrees and submodules.
-func dotGitFilesystems(path string) (dot, wt billy.Filesystem, err error) {
-	if path, err = filepath.Abs(path); err != nil {
-		return nil, nil, err
-	}
+// sshConfigFile is the OpenSSH client config whose Host blocks resolve SSH
+// host aliases in remote URLs. It can be replaced for testing.
+var sshConfigFile = "~/.ssh/config"
 
-	var fs billy.Filesystem
-	var fi os.FileInfo
-	for {
-		fs = osfs.New(path)
-		fi, err = fs.Stat(git.GitDirName)
-		if err == nil {
-			break
-		}
-		if !os.IsNotExist(err) {
-			return nil, nil, err
-		}
-		if dir := filepath.Dir(path); dir != path {
-			path = dir
-			continue
-		}
-		return nil, nil, git.ErrRepositoryNotExists
-	}
+// sshConfigs are the SSH configs read by path, since one run may resolve the
+// remotes of many repositories.
+var sshConfigs = map[string]*gitopen.SSHConfig{}
 
-	if fi.IsDir() {
-		dot, err = fs.Chroot(git.GitDirName)
-		return dot, fs, err
+// userSSHConfig returns the SSH config in sshConfigFile, which is empty when
+// it cannot be read.
+func userSSHConfig() *gitopen.SSHConfig {
+	path := homedir.Expand(sshConfigFile)
+	if config, ok := sshConfigs[path]; ok {
+		return config
 	}
-
-	dot, err = dotGitFileToFilesystem(path, fs)
+	config, err := gitopen.ReadSSHConfig(path)
 	if err != nil {
-		return nil, nil, err
+		logger.Debug("cannot read ssh config", "path", path, "error", err)
+		config = &gitopen.SSHConfig{}
+	} else {
+		logger.Debug("read ssh config", "path", path)
 	}
-	return dot, fs, nil
+	sshConfigs[path] = config
+	return config
 }
 
-// dotGitFileToFilesystem resolves a .git file to the git directory it points to.
-func dotGitFileToFilesystem(path string, fs billy.Filesystem) (billy.Filesystem, error) {
-	f, err := fs.Open(git.GitDirName)
-	if err != nil {
-		return nil, err
+// resolveSSHAlias returns remoteURL with its SSH host alias resolved to the
+// host it names in userSSHConfig.
+func resolveSSHAlias(remoteURL string) string {
+	rewritten, ok := userSSHConfig().RewriteURL(remoteURL)
+	if ok {
+		logger.Debug("resolved SSH host alias", "url", remoteURL, "resolved_url", rewritten)
 	}
-	defer f.Close()
+	return rewritten
+}
 
-	b, err := io.ReadAll(f)
+// getRemoteURL returns the URL of the remote of repo its settings select.
+func getRemoteURL(repo *git.Repository) (string, error) {
+	fast, settings, err := loadSettings(repo)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
+	return getRemoteURLFunc(repo, fast, settings.Remote)
+}
 
-	line := string(b)
-	const prefix = "gitdir: "
-	if !strings.HasPrefix(line, prefix) {
-		return nil, fmt.Errorf(".git file has no %s prefix", prefix)
-	}
+// resolvedRepository is a repository with its settings and the target
+// resolved from them, which callers share so that its config is read once.
+type resolvedRepository struct {
+	repo     *git.Repository
+	settings repoSettings
+	target   *Target
+}
 
-	gitdir := strings.TrimSpace(strings.Split(line[len(prefix):], "\n")[0])
-	if filepath.IsAbs(gitdir) {
-		return osfs.New(gitdir), nil
-	}
-	return osfs.New(fs.Join(path, gitdir)), nil
+// homeTarget returns a copy of the target of r for its home page.
+func (r *resolvedRepository) homeTarget() *Target {
+	target := *r.target
+	target.WebURL = target.HomeURL
+	return &target
 }
 
-// dotGitCommonDirectory returns the common git directory referenced by a
-// "commondir" file, or nil when the file does not exist.
-func dotGitCommonDirectory(fs billy.Filesystem) (billy.Filesystem, error) {
-	f, err := fs.Open("commondir")
-	if os.IsNotExist(err) {
-		return nil, nil
-	}
+// resolveWebURL resolves the Git repository in the current working directory.
+func resolveWebURL() (*resolvedRepository, error) {
+	repo, err := getCurrentGitDirectory()
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("error getting git directory: %w", err)
 	}
-	defer f.Close()
+	return resolveRepositoryWebURL(repo)
+}
 
-	b, err := io.ReadAll(f)
-	if err != nil {
-		return nil, err
-	}
-	if len(b) == 0 {
-		return nil, nil
+// resolveFastRepositoryWebURL is resolveWebURL for the repository fast, which
+// the fast path read but could not resolve, reusing its git directory and
+// config rather than searching for and reading them again.
+func resolveFastRepositoryWebURL(fast *fastRepository) (*resolvedRepository, error) {
+	if fast == nil {
+		return resolveWebURL()
 	}
-
-	common := strings.TrimSpace(string(b))
-	var commonDir billy.Filesystem
-	if filepath.IsAbs(common) {
-		commonDir = osfs.New(common)
-	} else {
-		commonDir = osfs.New(filepath.Join(fs.Root(), common))
+	repo, err := gitopen.OpenWorkTree(fast.gitDir, fast.workTree, logger)
+	if err != nil {
+		return nil, fmt.Errorf("error getting git directory: %w", err)
 	}
-	if _, err := commonDir.Stat(""); err != nil {
-		if os.IsNotExist(err) {
-			return nil, git.ErrRepositoryIncomplete
-		}
+	settings, err := fast.settings()
+	if err != nil {
 		return nil, err
 	}
-	return commonDir, nil
-}
-
-func getCurrentGitDirectory() (*git.Repository, error) {
-	return getCurrentGitDirectoryFunc()
+	return resolveRepositoryURLs(repo, fast, settings)
 }
 
-// getRemoteURLFunc is a variable that can be replaced for testing
-var getRemoteURLFunc = func(repo *git.Repository) (string, error) {
-	// Get the remote URL of the Git repository
-	remote, err := repo.Remote("origin")
+// resolveRepositoryWebURL reads the settings of repo and resolves its target.
+func resolveRepositoryWebURL(repo *git.Repository) (*resolvedRepository, error) {
+	fast, settings, err := loadSettings(repo)
 	if err != nil {
-		return "", err
-	}
-
-	urls := remote.Config().URLs
-	if len(urls) == 0 {
-		return "", fmt.Errorf("remote URL not found")
+		return nil, err
 	}
-
-	return urls[0], nil
-}
-
-func getRemoteURL(repo *git.Repository) (string, error) {
-	return getRemoteURLFunc(repo)
+	return resolveRepositoryURLs(repo, fast, settings)
 }
 
-// resolveWebURL returns the repository, its remote URL, and the converted web URL
-// for the Git repository in the current working directory.
-func resolveWebURL() (*git.Repository, string, string, error) {
-	repo, err := getCurrentGitDirectory()
+// resolveRepositoryURLs resolves the target of repo, whose config fast has
+// read, with the gitopen library: the remote URL, the web URL converted from
+// it or configured with git-open.webURL, and the page of HEAD.
+func resolveRepositoryURLs(repo *git.Repository, fast *fastRepository, settings repoSettings) (*resolvedRepository, error) {
+	remoteURL, err := getRemoteURLFunc(repo, fast, settings.Remote)
 	if err != nil {
-		return nil, "", "", fmt.Errorf("error getting git directory: %w", err)
+		return nil, fmt.Errorf("error getting remote URL: %w", err)
 	}
 
-	remoteURL, err := getRemoteURL(repo)
+	target, err := gitopen.ResolveRepository(context.Background(), repo, settings.options(fast, remoteURL))
 	if err != nil {
-		return nil, "", "", fmt.Errorf("error getting remote URL: %w", err)
-	}
-
-	webURL := convertToWebURL(remoteURL)
-	if webURL == "" {
-		return nil, "", "", fmt.Errorf("unsupported remote URL format: %s", remoteURL)
+		return nil, err
 	}
-
-	return repo, remoteURL, webURL, nil
+	return &resolvedRepository{repo: repo, settings: settings, target: target}, nil
 }
 
-var scpRemoteURLPattern = regexp.MustCompile(`^(?:[^@]+@)?([^:]+):(.+)$`)
-
+// convertToWebURL returns the web URL of the repository at rawURL, or "" when
+// the URL is not supported.
 func convertToWebURL(rawURL string) string {
-	raw := strings.TrimSpace(rawURL)
-	if raw == "" {
-		return ""
-	}
-
-	parsedURL, err := url.Parse(raw)
-	if err == nil && parsedURL.Host != "" && parsedURL.Scheme != "" {
-		// URL style: https://, http://, ssh:// or git+ssh://
-		path := strings.TrimPrefix(parsedURL.Path, "/")
-		if path == "" {
-			return ""
-		}
-
-		switch parsedURL.Scheme {
-		case "http", "https":
-			host := parsedURL.Host
-			return fmt.Sprintf("%s://%s/%s", parsedURL.Scheme, strings.TrimSuffix(host, "/"), strings.TrimSuffix(path, ".git"))
-		case "ssh", "git+ssh":
-			return fmt.Sprintf("https://%s/%s", parsedURL.Hostname(), strings.TrimSuffix(path, ".git"))
-		}
-
-		return ""
-	}
-
-	matches := scpRemoteURLPattern.FindStringSubmatch(raw)
-	if len(matches) != 3 {
-		return ""
-	}
-
-	host := matches[1]
-	path := strings.TrimPrefix(matches[2], "/")
-	if host == "" || path == "" {
+	remote, err := gitopen.ParseRemoteURL(rawURL)
+	if err != nil {
 		return ""
 	}
-
-	return fmt.Sprintf("https://%s/%s", host, strings.TrimSuffix(path, ".git"))
+	return remote.WebURL
 }
 
 // getBranchNameFunc is a variable that can be replaced for testing
@@ -301,7 +185,15 @@ var getBranchNameFunc = func(repo *git.Repository) (string, error) {
 		err = errors.New("detached HEAD")
 	}
 
-	ref, refErr := repo.Reference("HEAD", true)
+	// In a bare repository HEAD names the default branch even when that
+	// branch has no commits, e.g. a fresh "git init --bare" that was pushed
+	// a differently named branch
+	resolve := true
+	if _, wtErr := repo.Worktree(); errors.Is(wtErr, git.ErrIsBareRepository) {
+		resolve = false
+	}
+
+	ref, refErr := repo.Reference(plumbing.HEAD, resolve)
 	if refErr != nil {
 		return "", fmt.Errorf("error getting HEAD: %w", err)
 	}
@@ -318,32 +210,16 @@ func getBranchName(repo *git.Repository) (string, error) {
 	return getBranchNameFunc(repo)
 }
 
+// providers are the hosting services git-open detects and lays out pages for.
+var providers = gitopen.NewRegistry(gitopen.DefaultProviders()...)
+
 // getHostingService determines the Git hosting service from the remote URL.
-func getHostingService(remoteURL string) HostingService {
-	if strings.Contains(remoteURL, "github.com") {
-		return GitHub
-	}
-	if strings.Contains(remoteURL, "gitlab.com") {
-		return GitLab
-	}
-	if strings.Contains(remoteURL, "bitbucket.org") {
-		return Bitbucket
-	}
-	return Unknown
+func getHostingService(remoteURL string) *gitopen.Provider {
+	provider, _ := providers.Detect(remoteURL)
+	return provider
 }
 
 // buildBranchURL constructs the full URL for a given branch based on the hosting service.
 func buildBranchURL(baseURL, branchName, remoteURL string) string {
-	service := getHostingService(remoteURL)
-	switch service {
-	case GitHub:
-		return fmt.Sprintf("%s/tree/%s", baseURL, branchName)
-	case GitLab:
-		return fmt.Sprintf("%s/-/tree/%s", baseURL, branchName)
-	case Bitbucket:
-		return fmt.Sprintf("%s/src/%s", baseURL, branchName)
-	default:
-		// Default to GitHub-like path for unknown services or if no specific path is needed
-		return fmt.Sprintf("%s/tree/%s", baseURL, branchName)
-	}
+	return getHostingService(remoteURL).BranchURL(baseURL, branchName)
 }
@@ -7,10 +7,10 @@ import (
 	"strings"
 	"testing"
 
-	"github.com/go-git/go-billy/v5/osfs"
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/zhaochunqi/git-open/internal/testhelper"
+	"github.com/zhaochunqi/git-open/pkg/gitopen"
 )
 
 func Test_getBranchName_DetachedHEAD(t *testing.T) {
@@ -59,18 +59,18 @@ func Test_resolveWebURL_WorktreeConfigExtension(t *testing.T) {
 		t.Fatalf("close config failed: %v", err)
 	}
 
-	repo, remoteURL, webURL, err := resolveWebURL()
+	resolved, err := resolveWebURL()
 	if err != nil {
 		t.Fatalf("resolveWebURL() error = %v", err)
 	}
-	if remoteURL != "https://github.com/zhaochunqi/git-open.git" {
-		t.Errorf("resolveWebURL() remoteURL = %q, want %q", remoteURL, "https://github.com/zhaochunqi/git-open.git")
+	if resolved.target.RemoteURL != "https://github.com/zhaochunqi/git-open.git" {
+		t.Errorf("resolveWebURL() remoteURL = %q, want %q", resolved.target.RemoteURL, "https://github.com/zhaochunqi/git-open.git")
 	}
-	if webURL != "https://github.com/zhaochunqi/git-open" {
-		t.Errorf("resolveWebURL() webURL = %q, want %q", webURL, "https://github.com/zhaochunqi/git-open")
+	if resolved.target.HomeURL != "https://github.com/zhaochunqi/git-open" {
+		t.Errorf("resolveWebURL() homeURL = %q, want %q", resolved.target.HomeURL, "https://github.com/zhaochunqi/git-open")
 	}
 
-	branch, err := getBranchName(repo)
+	branch, err := getBranchName(resolved.repo)
 	if err != nil {
 		t.Fatalf("getBranchName() error = %v", err)
 	}
@@ -139,162 +139,13 @@ func Test_openRepositoryToleratingExtensions_Worktree(t *testing.T) {
 	}
 }
 
-func Test_dotGitFilesystems_NotFound(t *testing.T) {
+func Test_FindGitDir_NotFound(t *testing.T) {
 	// A plain temporary directory has no .git anywhere up to the filesystem
 	// root, so the lookup must report ErrRepositoryNotExists.
 	tmpDir := t.TempDir()
 
-	_, _, err := dotGitFilesystems(tmpDir)
+	_, _, err := gitopen.FindGitDir(tmpDir, gitopen.Discovery{}, logger)
 	if !errors.Is(err, git.ErrRepositoryNotExists) {
-		t.Errorf("dotGitFilesystems() error = %v, want %v", err, git.ErrRepositoryNotExists)
-	}
-}
-
-func Test_dotGitFileToFilesystem(t *testing.T) {
-	absGitdir := t.TempDir()
-
-	tests := []struct {
-		name     string
-		content  string
-		wantRoot string
-		wantErr  bool
-	}{
-		{
-			name:     "relative gitdir",
-			content:  "gitdir: .git-real\n",
-			wantRoot: ".git-real",
-		},
-		{
-			name:     "absolute gitdir",
-			content:  "gitdir: " + absGitdir + "\n",
-			wantRoot: absGitdir,
-		},
-		{
-			name:    "missing gitdir prefix",
-			content: "not a gitdir pointer\n",
-			wantErr: true,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			tmpDir := t.TempDir()
-			if err := os.WriteFile(filepath.Join(tmpDir, ".git"), []byte(tt.content), 0644); err != nil {
-				t.Fatal(err)
-			}
-
-			fs, err := dotGitFileToFilesystem(tmpDir, osfs.New(tmpDir))
-			if (err != nil) != tt.wantErr {
-				t.Fatalf("dotGitFileToFilesystem() error = %v, wantErr %v", err, tt.wantErr)
-			}
-			if tt.wantErr {
-				return
-			}
-
-			want := tt.wantRoot
-			if !filepath.IsAbs(want) {
-				want = filepath.Join(tmpDir, want)
-			}
-			// osfs.Root resolves symlinks (e.g. /var -> /private/var on macOS).
-			if resolved, err := filepath.EvalSymlinks(want); err == nil {
-				want = resolved
-			}
-			if fs.Root() != want {
-				t.Errorf("dotGitFileToFilesystem() root = %q, want %q", fs.Root(), want)
-			}
-		})
-	}
-}
-
-func Test_dotGitFileToFilesystem_MissingFile(t *testing.T) {
-	tmpDir := t.TempDir()
-
-	_, err := dotGitFileToFilesystem(tmpDir, osfs.New(tmpDir))
-	if err == nil {
-		t.Error("dotGitFileToFilesystem() expected error for missing .git file, got nil")
+		t.Errorf("FindGitDir() error = %v, want %v", err, git.ErrRepositoryNotExists)
 	}
 }
-
-func Test_dotGitCommonDirectory(t *testing.T) {
-	t.Run("no commondir file", func(t *testing.T) {
-		fs := osfs.New(t.TempDir())
-		common, err := dotGitCommonDirectory(fs)
-		if err != nil {
-			t.Fatalf("dotGitCommonDirectory() error = %v", err)
-		}
-		if common != nil {
-			t.Errorf("dotGitCommonDirectory() = %v, want nil", common)
-		}
-	})
-
-	t.Run("empty commondir file", func(t *testing.T) {
-		tmpDir := t.TempDir()
-		if err := os.WriteFile(filepath.Join(tmpDir, "commondir"), nil, 0644); err != nil {
-			t.Fatal(err)
-		}
-		common, err := dotGitCommonDirectory(osfs.New(tmpDir))
-		if err != nil {
-			t.Fatalf("dotGitCommonDirectory() error = %v", err)
-		}
-		if common != nil {
-			t.Errorf("dotGitCommonDirectory() = %v, want nil", common)
-		}
-	})
-
-	t.Run("relative commondir", func(t *testing.T) {
-		tmpDir := t.TempDir()
-		gitdir := filepath.Join(tmpDir, "gitdir")
-		commonDir := filepath.Join(tmpDir, "common")
-		for _, dir := range []string{gitdir, commonDir} {
-			if err := os.MkdirAll(dir, 0755); err != nil {
-				t.Fatal(err)
-			}
-		}
-		if err := os.WriteFile(filepath.Join(gitdir, "commondir"), []byte("../common\n"), 0644); err != nil {
-			t.Fatal(err)
-		}
-		common, err := dotGitCommonDirectory(osfs.New(gitdir))
-		if err != nil {
-			t.Fatalf("dotGitCommonDirectory() error = %v", err)
-		}
-		// osfs.Root resolves symlinks (e.g. /var -> /private/var on macOS).
-		wantDir, err := filepath.EvalSymlinks(commonDir)
-		if err != nil {
-			t.Fatal(err)
-		}
-		if common == nil || common.Root() != wantDir {
-			t.Errorf("dotGitCommonDirectory() root = %v, want %q", common, wantDir)
-		}
-	})
-
-	t.Run("absolute commondir", func(t *testing.T) {
-		tmpDir := t.TempDir()
-		commonDir := t.TempDir()
-		if err := os.WriteFile(filepath.Join(tmpDir, "commondir"), []byte(commonDir+"\n"), 0644); err != nil {
-			t.Fatal(err)
-		}
-		common, err := dotGitCommonDirectory(osfs.New(tmpDir))
-		if err != nil {
-			t.Fatalf("dotGitCommonDirectory() error = %v", err)
-		}
-		// osfs.Root resolves symlinks (e.g. /var -> /private/var on macOS).
-		wantDir, err := filepath.EvalSymlinks(commonDir)
-		if err != nil {
-			t.Fatal(err)
-		}
-		if common == nil || common.Root() != wantDir {
-			t.Errorf("dotGitCommonDirectory() root = %v, want %q", common, wantDir)
-		}
-	})
-
-	t.Run("commondir does not exist", func(t *testing.T) {
-		tmpDir := t.TempDir()
-		if err := os.WriteFile(filepath.Join(tmpDir, "commondir"), []byte("missing\n"), 0644); err != nil {
-			t.Fatal(err)
-		}
-		_, err := dotGitCommonDirectory(osfs.New(tmpDir))
-		if !errors.Is(err, git.ErrRepositoryIncomplete) {
-			t.Errorf("dotGitCommonDirectory() error = %v, want %v", err, git.ErrRepositoryIncomplete)
-		}
-	})
-}
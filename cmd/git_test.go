@@ -1,16 +1,30 @@
 package cmd
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/config"
+	"github.com/spf13/cobra"
 	"github.com/zhaochunqi/git-open/internal/testhelper"
+	"github.com/zhaochunqi/git-open/pkg/gitopen"
 )
 
+// TestMain keeps the system and user git and SSH config from affecting the
+// tests.
+func TestMain(m *testing.M) {
+	os.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	os.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	sshConfigFile = os.DevNull
+	os.Exit(m.Run())
+}
+
 func Test_getCurrentGitDirectory(t *testing.T) {
 	_, cleanup := testhelper.SetupTestRepo(t, "https://github.com/zhaochunqi/git-open.git", "main")
 	defer cleanup()
@@ -344,33 +358,33 @@ func Test_getHostingService(t *testing.T) {
 	tests := []struct {
 		name      string
 		remoteURL string
-		want      HostingService
+		want      string
 	}{
 		{
 			name:      "github",
 			remoteURL: "https://github.com/user/repo.git",
-			want:      GitHub,
+			want:      "github",
 		},
 		{
 			name:      "gitlab",
 			remoteURL: "https://gitlab.com/user/repo.git",
-			want:      GitLab,
+			want:      "gitlab",
 		},
 		{
 			name:      "bitbucket",
 			remoteURL: "https://bitbucket.org/user/repo.git",
-			want:      Bitbucket,
+			want:      "bitbucket",
 		},
 		{
 			name:      "unknown service",
 			remoteURL: "https://example.com/user/repo.git",
-			want:      Unknown,
+			want:      "unknown",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := getHostingService(tt.remoteURL); got != tt.want {
+			if got := getHostingService(tt.remoteURL).Name; got != tt.want {
 				t.Errorf("getHostingService() = %v, want %v", got, tt.want)
 			}
 		})
@@ -478,7 +492,7 @@ func Test_getRemoteURL_EmptyURLs(t *testing.T) {
 	}()
 
 	// Mock getRemoteURLFunc to simulate empty URLs error
-	getRemoteURLFunc = func(repo *git.Repository) (string, error) {
+	getRemoteURLFunc = func(repo *git.Repository, fast *fastRepository, name string) (string, error) {
 		return "", fmt.Errorf("remote URL not found")
 	}
 
@@ -501,3 +515,126 @@ func Test_getRemoteURL_EmptyURLs(t *testing.T) {
 		t.Errorf("Expected error message 'remote URL not found', got '%s'", err.Error())
 	}
 }
+
+func Test_getRemoteURL_EffectiveConfig(t *testing.T) {
+	dir, cleanup := testhelper.SetupTestRepo(t, "https://github.com/owner/repo.git", "main")
+	defer cleanup()
+
+	global := filepath.Join(dir, "global")
+	if err := os.WriteFile(global, []byte("[url \"git@github.com:\"]\n\tinsteadOf = https://github.com/\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GIT_CONFIG_GLOBAL", global)
+
+	repo, err := getCurrentGitDirectory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := getRemoteURL(repo); err != nil || got != "git@github.com:owner/repo.git" {
+		t.Errorf("getRemoteURL() = %q, %v, want the global insteadOf applied", got, err)
+	}
+}
+
+func Test_rootCmd_MatchesLibrary(t *testing.T) {
+	tests := []struct {
+		name      string
+		remoteURL string
+		branch    string
+		setup     func(t *testing.T, dir string, repo *git.Repository)
+	}{
+		{"branch", "git@github.com:owner/repo.git", "feature", nil},
+		{"nested branch", "https://gitlab.com/group/sub/repo.git", "feature/a b", nil},
+		{"default branch", "git@bitbucket.org:owner/repo.git", "main", nil},
+		{"SSH host alias", "git@github.com-work:owner/repo.git", "feature", nil},
+		{"git-open config", "git@git.example.com:owner/repo.git", "trunk", func(t *testing.T, dir string, repo *git.Repository) {
+			appendGitConfig(t, dir, "[git-open]\n\tprovider = gitlab\n\tdefaultBranch = develop\n\twebURL = https://mirror.example.com/owner/repo\n")
+		}},
+		{"global insteadOf", "https://github.com/owner/repo.git", "feature", func(t *testing.T, dir string, repo *git.Repository) {
+			global := filepath.Join(dir, "global")
+			if err := os.WriteFile(global, []byte("[url \"https://gitlab.com/\"]\n\tinsteadOf = https://github.com/\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			t.Setenv("GIT_CONFIG_GLOBAL", global)
+		}},
+		{"detached at a tag", "git@github.com:owner/repo.git", "main", func(t *testing.T, dir string, repo *git.Repository) {
+			head, err := repo.Head()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := repo.CreateTag("v1.0.0", head.Hash(), nil); err != nil {
+				t.Fatal(err)
+			}
+			detachHead(t, repo, head.Hash())
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, cleanup := testhelper.SetupTestRepo(t, tt.remoteURL, tt.branch)
+			defer cleanup()
+			if tt.setup != nil {
+				repo, err := git.PlainOpen(dir)
+				if err != nil {
+					t.Fatal(err)
+				}
+				tt.setup(t, dir, repo)
+			}
+
+			target, err := gitopen.Resolve(context.Background(), dir, gitopen.Options{})
+			if err != nil {
+				t.Fatalf("gitopen.Resolve() error = %v", err)
+			}
+
+			buf := new(bytes.Buffer)
+			cmd := &cobra.Command{}
+			cmd.SetOut(buf)
+			cmd.SetErr(new(bytes.Buffer))
+			cmd.Flags().Bool("plain", true, "")
+			if err := rootCmd.RunE(cmd, []string{}); err != nil {
+				t.Fatalf("rootCmd.RunE() error = %v", err)
+			}
+			if want := "Web URL: " + target.WebURL + "\n"; buf.String() != want {
+				t.Errorf("rootCmd output = %q, library resolved %q", buf.String(), want)
+			}
+		})
+	}
+}
+
+func Test_rootCmd_SSHHostAlias(t *testing.T) {
+	_, cleanup := testhelper.SetupTestRepo(t, "git@github.com-work:owner/repo.git", "main")
+	defer cleanup()
+
+	sshConfig := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(sshConfig, []byte("Host github.com-work\n\tHostName github.com\n\tIdentityFile ~/.ssh/work\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	original := sshConfigFile
+	sshConfigFile = sshConfig
+	t.Cleanup(func() { sshConfigFile = original })
+
+	originalOutput, originalLogger, originalVerbose := traceOutput, logger, verbose
+	t.Cleanup(func() { traceOutput, logger, verbose = originalOutput, originalLogger, originalVerbose })
+
+	for _, fast := range []bool{true, false} {
+		if !fast {
+			withoutFastPath(t)
+		}
+		trace := new(bytes.Buffer)
+		traceOutput, verbose = trace, true
+		initTracing()
+
+		buf := new(bytes.Buffer)
+		cmd := &cobra.Command{}
+		cmd.SetOut(buf)
+		cmd.Flags().Bool("plain", true, "")
+		if err := rootCmd.RunE(cmd, []string{}); err != nil {
+			t.Fatalf("rootCmd.RunE() error = %v", err)
+		}
+		if want := "Web URL: https://github.com/owner/repo\n"; buf.String() != want {
+			t.Errorf("rootCmd output with fast path %v = %q, want %q", fast, buf.String(), want)
+		}
+		if want := `msg="resolved SSH host alias" url=git@github.com-work:owner/repo.git resolved_url=git@github.com:owner/repo.git`; !strings.Contains(trace.String(), want) {
+			t.Errorf("trace with fast path %v lacks %q:\n%s", fast, want, trace.String())
+		}
+	}
+}
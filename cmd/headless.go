@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+)
+
+// PrintHyperlink wraps the URL printed in headless sessions in an OSC 8
+// escape sequence, so terminals that support it make it clickable.
+var PrintHyperlink bool
+
+// PrintQRCode additionally renders the URL printed in headless sessions as a
+// QR code, using the qrencode program when it is installed.
+var PrintQRCode bool
+
+// errHeadless is returned by openURLInBrowser when there is no display to open
+// the URL on, for the caller to print it instead.
+var errHeadless = errors.New("no display to open a browser on")
+
+// writerIsTerminal reports whether w is a terminal, can be mocked for testing
+var writerIsTerminal = func(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	return ok && isTerminal(f)
+}
+
+// qrCodeRenderer renders text as a terminal QR code, can be mocked for testing
+var qrCodeRenderer = func(text string) (string, error) {
+	out, err := exec.Command("qrencode", "-t", "UTF8", text).Output()
+	return string(out), err
+}
+
+// isHeadless reports whether there is no display a browser could be opened
+// on: inside an SSH session, or on Linux with neither X11 nor Wayland. WSL is
+// not headless as URLs are handed to the Windows desktop.
+func isHeadless(platform string) bool {
+	if os.Getenv("SSH_CONNECTION") != "" {
+		return true
+	}
+	if platform != "linux" || isWSL() {
+		return false
+	}
+	return os.Getenv("DISPLAY") == "" && os.Getenv("WAYLAND_DISPLAY") == ""
+}
+
+// printURLForHeadless prints url to w instead of opening it, optionally as an
+// OSC 8 hyperlink when w is a terminal and followed by a QR code.
+func printURLForHeadless(w io.Writer, url string) error {
+	text := url
+	if PrintHyperlink && writerIsTerminal(w) {
+		text = osc8Hyperlink(url, url)
+	}
+	if _, err := fmt.Fprintf(w, "Web URL: %s\n", text); err != nil {
+		return err
+	}
+
+	if !PrintQRCode {
+		return nil
+	}
+	code, err := qrCodeRenderer(url)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Cannot render QR code (is qrencode installed?): %v\n", err)
+		return nil
+	}
+	_, err = fmt.Fprint(w, code)
+	return err
+}
+
+// osc8Hyperlink returns text as a terminal hyperlink to url.
+func osc8Hyperlink(url, text string) string {
+	return "\x1b]8;;" + url + "\x1b\\" + text + "\x1b]8;;\x1b\\"
+}
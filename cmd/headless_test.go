@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func Test_isHeadless(t *testing.T) {
+	originalReadOSRelease := readOSRelease
+	t.Cleanup(func() { readOSRelease = originalReadOSRelease })
+
+	tests := []struct {
+		name           string
+		platform       string
+		wsl            bool
+		sshConnection  string
+		display        string
+		waylandDisplay string
+		want           bool
+	}{
+		{name: "linux with X11", platform: "linux", display: ":0", want: false},
+		{name: "linux with Wayland", platform: "linux", waylandDisplay: "wayland-0", want: false},
+		{name: "linux without display", platform: "linux", want: true},
+		{name: "linux over SSH with forwarded X11", platform: "linux", display: "localhost:10.0", sshConnection: "10.0.0.1 50000 10.0.0.2 22", want: true},
+		{name: "WSL without display", platform: "linux", wsl: true, want: false},
+		{name: "macOS", platform: "darwin", want: false},
+		{name: "macOS over SSH", platform: "darwin", sshConnection: "10.0.0.1 50000 10.0.0.2 22", want: true},
+		{name: "windows", platform: "windows", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("SSH_CONNECTION", tt.sshConnection)
+			t.Setenv("DISPLAY", tt.display)
+			t.Setenv("WAYLAND_DISPLAY", tt.waylandDisplay)
+			readOSRelease = func() (string, error) {
+				if tt.wsl {
+					return "5.15.153.1-microsoft-standard-WSL2", nil
+				}
+				return "6.8.0-45-generic", nil
+			}
+
+			if got := isHeadless(tt.platform); got != tt.want {
+				t.Errorf("isHeadless(%q) = %v, want %v", tt.platform, got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_printURLForHeadless(t *testing.T) {
+	const url = "https://github.com/zhaochunqi/git-open"
+
+	originalRenderer := qrCodeRenderer
+	originalHyperlink := PrintHyperlink
+	originalQRCode := PrintQRCode
+	originalWriterIsTerminal := writerIsTerminal
+	t.Cleanup(func() {
+		writerIsTerminal = originalWriterIsTerminal
+		qrCodeRenderer = originalRenderer
+		PrintHyperlink = originalHyperlink
+		PrintQRCode = originalQRCode
+	})
+
+	tests := []struct {
+		name      string
+		hyperlink bool
+		terminal  bool
+		qrCode    bool
+		qrErr     error
+		want      string
+	}{
+		{
+			name: "plain",
+			want: "Web URL: " + url + "\n",
+		},
+		{
+			name:      "hyperlink",
+			hyperlink: true,
+			terminal:  true,
+			want:      "Web URL: \x1b]8;;" + url + "\x1b\\" + url + "\x1b]8;;\x1b\\\n",
+		},
+		{
+			name:      "hyperlink not to a terminal",
+			hyperlink: true,
+			want:      "Web URL: " + url + "\n",
+		},
+		{
+			name:   "QR code",
+			qrCode: true,
+			want:   "Web URL: " + url + "\n[qr]\n",
+		},
+		{
+			name:   "QR code renderer missing",
+			qrCode: true,
+			qrErr:  errors.New("executable file not found"),
+			want:   "Web URL: " + url + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			writerIsTerminal = func(w io.Writer) bool { return tt.terminal }
+			PrintHyperlink = tt.hyperlink
+			PrintQRCode = tt.qrCode
+			qrCodeRenderer = func(text string) (string, error) {
+				if text != url {
+					t.Errorf("qrCodeRenderer() called with %q, want %q", text, url)
+				}
+				return "[qr]\n", tt.qrErr
+			}
+
+			if err := printURLForHeadless(buf, url); err != nil {
+				t.Fatalf("printURLForHeadless() error = %v", err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("printURLForHeadless() output = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_showWebURL_Headless(t *testing.T) {
+	const url = "https://github.com/zhaochunqi/git-open"
+
+	originalGetPlatform := getPlatform
+	originalReadOSRelease := readOSRelease
+	t.Cleanup(func() {
+		getPlatform = originalGetPlatform
+		readOSRelease = originalReadOSRelease
+	})
+	t.Setenv("BROWSER", "")
+	t.Setenv("SSH_CONNECTION", "")
+	t.Setenv("DISPLAY", "")
+	t.Setenv("WAYLAND_DISPLAY", "")
+	getPlatform = func() string { return "linux" }
+	readOSRelease = func() (string, error) { return "6.8.0-45-generic", nil }
+
+	launched := false
+	withCommandRunner(t, func(name string, args ...string) error {
+		launched = true
+		return nil
+	})
+
+	if err := openURLInBrowser(url); !errors.Is(err, errHeadless) {
+		t.Fatalf("openURLInBrowser() error = %v, want %v", err, errHeadless)
+	}
+	if launched {
+		t.Error("openURLInBrowser() launched a browser without a display")
+	}
+
+	cmd := &cobra.Command{}
+	cmd.Flags().Bool("plain", false, "")
+	buf := new(bytes.Buffer)
+	cmd.SetOut(buf)
+	if err := showWebURL(cmd, url, repoSettings{}); err != nil {
+		t.Fatalf("showWebURL() error = %v", err)
+	}
+	if got, want := buf.String(), "Web URL: "+url+"\n"; got != want {
+		t.Errorf("showWebURL() output = %q, want %q", got, want)
+	}
+}
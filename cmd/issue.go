@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/spf13/cobra"
+)
+
+// IssueTemplate is the issue-tracker URL template from the "issues" config
+// key, e.g. "https://jira.example.com/browse/{{.Issue}}".
+var IssueTemplate string
+
+// DocsTemplate is the documentation URL template from the "docs" config key.
+var DocsTemplate string
+
+// issueTarget is the data of issue-tracker templates: the Target fields and
+// the issue given on the command line.
+type issueTarget struct {
+	*Target
+	// Issue is the issue number or key, empty for the issue list.
+	Issue string
+}
+
+// issueCmd represents the issue command
+var issueCmd = &cobra.Command{
+	Use:   "issue [number]",
+	Short: "Open the issue tracker of the repository",
+	Long: `Open an issue of the Git repository in the current working directory, or the
+list of issues without a number. The "issues" config key, usually set in the
+project's .git-open.yaml, replaces the hosting service's tracker with a URL
+template, e.g. https://jira.example.com/browse/{{.Issue}}.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var issue string
+		if len(args) > 0 {
+			issue = strings.TrimPrefix(args[0], "#")
+		}
+
+		target, settings, err := currentTarget()
+		if err != nil {
+			return err
+		}
+		issueURL, err := buildIssueURL(&issueTarget{Target: target, Issue: issue})
+		if err != nil {
+			return err
+		}
+		return showWebURL(cmd, issueURL, settings)
+	},
+}
+
+// docsCmd represents the docs command
+var docsCmd = &cobra.Command{
+	Use:   "docs",
+	Short: "Open the documentation of the repository",
+	Long: `Open the documentation configured with the "docs" config key, usually set in
+the project's .git-open.yaml. The URL may use the fields of --format templates,
+e.g. https://docs.example.com/{{.Repo}}.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if DocsTemplate == "" {
+			return errors.New(`no documentation URL configured; set "docs" in .git-open.yaml`)
+		}
+
+		target, settings, err := currentTarget()
+		if err != nil {
+			return err
+		}
+		docsURL, err := renderURLTemplate("docs", DocsTemplate, target)
+		if err != nil {
+			return err
+		}
+		return showWebURL(cmd, docsURL, settings)
+	},
+}
+
+// currentTarget resolves the Target and settings of the repository in the
+// current working directory.
+func currentTarget() (*Target, repoSettings, error) {
+	resolved, err := resolveWebURL()
+	if err != nil {
+		return nil, repoSettings{}, err
+	}
+	return resolved.homeTarget(), resolved.settings, nil
+}
+
+// buildIssueURL returns the URL of target's issue, or of its issue list when
+// it has none, from IssueTemplate or the hosting service.
+func buildIssueURL(target *issueTarget) (string, error) {
+	if IssueTemplate != "" {
+		return renderURLTemplate("issues", IssueTemplate, target)
+	}
+
+	if provider, ok := providers.Lookup(target.Provider); ok {
+		if issues, ok := provider.IssueURL(target.WebURL, target.Issue); ok {
+			return issues, nil
+		}
+	}
+	return "", errors.New(`no issue tracker known for this remote; set "issues" in .git-open.yaml`)
+}
+
+// renderURLTemplate renders the URL template of the config key name with data.
+func renderURLTemplate(name, text string, data any) (string, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s template %q: %w", name, text, err)
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("error executing %s template %q: %w", name, text, err)
+	}
+	return strings.TrimSpace(b.String()), nil
+}
+
+func init() {
+	for _, c := range []*cobra.Command{issueCmd, docsCmd} {
+		c.Flags().BoolP("plain", "p", false, "Just print the web url without opening.")
+		rootCmd.AddCommand(c)
+	}
+}
@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// Links maps the names of custom links from the "links" config key to URL
+// templates, e.g. grafana: https://grafana.example.com/d/{{.Repo}}.
+var Links map[string]string
+
+// HostLinks maps web hosts to links that only apply to repositories on that
+// host, from the "hostLinks" config key. They take precedence over Links.
+var HostLinks map[string]map[string]string
+
+// goCmd represents the go command
+var goCmd = &cobra.Command{
+	Use:   "go [name]",
+	Short: "Open a custom link of the repository",
+	Long: `Open one of the custom links configured under "links" (or "hostLinks" for the
+repository's host), expanding its URL template with the fields of --format
+templates, e.g. grafana: https://grafana.example.com/d/{{.Repo}}.
+Without a name, the links available in the repository are listed.`,
+	Args: cobra.MaximumNArgs(1),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		var host string
+		if _, ok := completionRepository(); ok {
+			if target, _, err := currentTarget(); err == nil {
+				host = target.Host
+			}
+		}
+		return sortedKeys(linksFor(host)), cobra.ShellCompDirectiveNoFileComp
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		target, settings, err := currentTarget()
+		if err != nil {
+			return err
+		}
+		links := linksFor(target.Host)
+
+		if len(args) == 0 {
+			for _, name := range sortedKeys(links) {
+				linkURL, err := renderURLTemplate(name, links[name], target)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "%s\t%s\n", name, linkURL)
+			}
+			return nil
+		}
+
+		name := strings.ToLower(args[0])
+		tmpl, ok := links[name]
+		if !ok {
+			if len(links) == 0 {
+				return fmt.Errorf(`unknown link %q: no links configured; add them under "links" in the config`, args[0])
+			}
+			return fmt.Errorf("unknown link %q (available: %s)", args[0], strings.Join(sortedKeys(links), ", "))
+		}
+		linkURL, err := renderURLTemplate(name, tmpl, target)
+		if err != nil {
+			return err
+		}
+		return showWebURL(cmd, linkURL, settings)
+	},
+}
+
+// linksFor returns the links of repositories on host: Links overridden by the
+// HostLinks of host.
+func linksFor(host string) map[string]string {
+	links := make(map[string]string, len(Links))
+	for name, tmpl := range Links {
+		links[name] = tmpl
+	}
+	for name, tmpl := range HostLinks[strings.ToLower(host)] {
+		links[name] = tmpl
+	}
+	return links
+}
+
+// hostLinksSetting returns the "hostLinks" config key as a map of host to
+// links. Like "browsers", it is read as a whole since host names contain dots.
+func hostLinksSetting() map[string]map[string]string {
+	hosts := make(map[string]map[string]string)
+	for host, value := range viper.GetStringMap("hostLinks") {
+		links, ok := value.(map[string]any)
+		if !ok {
+			continue
+		}
+		hosts[host] = make(map[string]string, len(links))
+		for name, tmpl := range links {
+			hosts[host][strings.ToLower(name)] = fmt.Sprint(tmpl)
+		}
+	}
+	return hosts
+}
+
+// sortedKeys returns the keys of m in order.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func init() {
+	goCmd.Flags().BoolP("plain", "p", false, "Just print the web url without opening.")
+	rootCmd.AddCommand(goCmd)
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/zhaochunqi/git-open/internal/testhelper"
+)
+
+func Test_goCmd(t *testing.T) {
+	dir, cleanup := testhelper.SetupTestRepo(t, "git@github.example.com:team/service.git", "main")
+	defer cleanup()
+
+	withProjectConfig(t, dir, `
+links:
+  grafana: https://grafana.example.com/d/{{.Repo}}
+  sentry: https://sentry.example.com/{{.Owner}}/{{.Repo}}
+`, `
+links:
+  sentry: https://sentry.io/organizations/{{.Owner}}/projects/{{.Repo}}
+  ci: https://ci.example.com/{{.Repo}}/{{.Branch}}
+hostLinks:
+  github.example.com:
+    ci: https://jenkins.example.com/job/{{.Repo}}
+`)
+
+	run := func(args ...string) (string, error) {
+		buf := new(bytes.Buffer)
+		cmd := &cobra.Command{}
+		cmd.SetOut(buf)
+		cmd.Flags().Bool("plain", true, "")
+		err := goCmd.RunE(cmd, args)
+		return buf.String(), err
+	}
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"grafana", "https://grafana.example.com/d/service"},
+		{"Sentry", "https://sentry.io/organizations/team/projects/service"},
+		{"ci", "https://jenkins.example.com/job/service"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := run(tt.name)
+			if err != nil {
+				t.Fatalf("goCmd.RunE() error = %v", err)
+			}
+			if want := "Web URL: " + tt.want + "\n"; got != want {
+				t.Errorf("goCmd output = %q, want %q", got, want)
+			}
+		})
+	}
+
+	t.Run("unknown link", func(t *testing.T) {
+		_, err := run("kibana")
+		if err == nil || !strings.Contains(err.Error(), "ci, grafana, sentry") {
+			t.Errorf("goCmd.RunE() error = %v, want the available links listed", err)
+		}
+	})
+
+	t.Run("list", func(t *testing.T) {
+		got, err := run()
+		if err != nil {
+			t.Fatalf("goCmd.RunE() error = %v", err)
+		}
+		want := "ci\thttps://jenkins.example.com/job/service\n" +
+			"grafana\thttps://grafana.example.com/d/service\n" +
+			"sentry\thttps://sentry.io/organizations/team/projects/service\n"
+		if got != want {
+			t.Errorf("goCmd output = %q, want %q", got, want)
+		}
+	})
+}
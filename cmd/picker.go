@@ -0,0 +1,264 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// errNoSelection is returned when the picker is left without picking a target.
+var errNoSelection = errors.New("no target selected")
+
+// maxPickerRows is how many targets the interactive picker shows at once.
+const maxPickerRows = 10
+
+// maxNumberedRows is how many targets the numbered prompt lists before asking
+// for text to narrow them down, e.g. for the files of a large repository.
+const maxNumberedRows = 50
+
+// pickerTarget is a page offered by the interactive picker.
+type pickerTarget struct {
+	Label string
+	URL   string
+}
+
+// pickTargetFunc asks the user to pick one of targets. It can be replaced for
+// testing.
+var pickTargetFunc = pickTarget
+
+// pickTarget shows the fuzzy-filterable picker when stdin and stdout are a
+// terminal, or a numbered prompt otherwise.
+func pickTarget(in io.Reader, out io.Writer, targets []pickerTarget) (pickerTarget, error) {
+	if stdin, ok := in.(*os.File); ok && os.Getenv("TERM") != "dumb" {
+		if stdout, ok := out.(*os.File); ok && isTerminal(stdout) {
+			if restore, err := makeRaw(stdin); err == nil {
+				defer restore()
+				return fuzzyPick(bufio.NewReader(stdin), stdout, targets)
+			}
+		}
+	}
+	return numberedPick(bufio.NewReader(in), out, targets)
+}
+
+// interactiveTargets lists the pages of the resolved repository offered by the
+// picker: the home and branch pages, the hosting service's pages, the web URLs
+// of the other remotes and the custom links.
+func interactiveTargets(resolved *resolvedRepository) []pickerTarget {
+	repo, settings, target := resolved.repo, resolved.settings, resolved.homeTarget()
+	remoteURL, webURL := target.RemoteURL, target.HomeURL
+	targets := []pickerTarget{{"home", webURL}}
+	provider := settings.hostingService(remoteURL)
+
+	if branchName := target.Branch; branchName != "" && settings.shouldAppendBranch(branchName) {
+		targets = append(targets, pickerTarget{"branch " + branchName, settings.buildBranchURL(webURL, branchName, remoteURL)})
+	}
+	if pageURL, ok := provider.PageURL(webURL, provider.PullRequestsPath); ok {
+		targets = append(targets, pickerTarget{"pull requests", pageURL})
+	}
+	if pageURL, ok := provider.PageURL(webURL, provider.CIPath); ok {
+		targets = append(targets, pickerTarget{"ci", pageURL})
+	}
+
+	if issuesURL, err := buildIssueURL(&issueTarget{Target: target}); err == nil {
+		targets = append(targets, pickerTarget{"issues", issuesURL})
+	}
+	if pageURL, ok := provider.PageURL(webURL, provider.ReleasesPath); ok {
+		targets = append(targets, pickerTarget{"releases", pageURL})
+	}
+	if DocsTemplate != "" {
+		if docsURL, err := renderURLTemplate("docs", DocsTemplate, target); err == nil {
+			targets = append(targets, pickerTarget{"docs", docsURL})
+		}
+	}
+
+	if remotes, err := repo.Remotes(); err == nil {
+		sort.Slice(remotes, func(i, j int) bool { return remotes[i].Config().Name < remotes[j].Config().Name })
+		for _, remote := range remotes {
+			if config := remote.Config(); config.Name != settings.Remote && len(config.URLs) > 0 {
+				if remoteWebURL := convertToWebURL(config.URLs[0]); remoteWebURL != "" {
+					targets = append(targets, pickerTarget{"remote " + config.Name, remoteWebURL})
+				}
+			}
+		}
+	}
+
+	links := linksFor(target.Host)
+	for _, name := range sortedKeys(links) {
+		if linkURL, err := renderURLTemplate(name, links[name], target); err == nil {
+			targets = append(targets, pickerTarget{"link " + name, linkURL})
+		}
+	}
+	return targets
+}
+
+// filterTargets returns the targets whose label fuzzily matches query, best
+// matches first.
+func filterTargets(targets []pickerTarget, query string) []pickerTarget {
+	type match struct {
+		target pickerTarget
+		score  int
+	}
+	var matches []match
+	for _, target := range targets {
+		if score, ok := fuzzyMatch(query, target.Label); ok {
+			matches = append(matches, match{target, score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].score < matches[j].score })
+
+	filtered := make([]pickerTarget, len(matches))
+	for i, m := range matches {
+		filtered[i] = m.target
+	}
+	return filtered
+}
+
+// fuzzyMatch reports whether the characters of query appear in text in order,
+// ignoring case, with a score that is lower the earlier and closer together
+// they are.
+func fuzzyMatch(query, text string) (int, bool) {
+	text = strings.ToLower(text)
+	score, pos := 0, 0
+	for _, r := range strings.ToLower(query) {
+		i := strings.IndexRune(text[pos:], r)
+		if i < 0 {
+			return 0, false
+		}
+		score += i
+		pos += i + len(string(r))
+	}
+	return score, true
+}
+
+// fuzzyPick runs the interactive picker on a terminal in raw mode: typing
+// filters the targets, the arrow keys (or Ctrl-P and Ctrl-N) move the
+// selection, Enter picks it and Esc or Ctrl-C cancels.
+func fuzzyPick(in *bufio.Reader, out io.Writer, targets []pickerTarget) (pickerTarget, error) {
+	var query []rune
+	selected, drawn := 0, 0
+	fmt.Fprint(out, "\x1b[?25l")
+	defer func() {
+		clearLines(out, drawn)
+		fmt.Fprint(out, "\x1b[?25h")
+	}()
+
+	for {
+		filtered := filterTargets(targets, string(query))
+		if selected >= len(filtered) {
+			selected = max(len(filtered)-1, 0)
+		}
+		clearLines(out, drawn)
+		drawn = drawPicker(out, string(query), filtered, selected)
+
+		r, _, err := in.ReadRune()
+		if err != nil {
+			return pickerTarget{}, errNoSelection
+		}
+		switch r {
+		case '\r', '\n':
+			if len(filtered) > 0 {
+				return filtered[selected], nil
+			}
+		case 3: // Ctrl-C
+			return pickerTarget{}, errNoSelection
+		case 0x1b: // Esc, or the start of an arrow key
+			if in.Buffered() == 0 {
+				return pickerTarget{}, errNoSelection
+			}
+			seq := make([]byte, 2)
+			if _, err := io.ReadFull(in, seq); err != nil {
+				return pickerTarget{}, errNoSelection
+			}
+			switch string(seq) {
+			case "[A", "OA":
+				selected = max(selected-1, 0)
+			case "[B", "OB":
+				selected = min(selected+1, max(len(filtered)-1, 0))
+			}
+		case 16: // Ctrl-P
+			selected = max(selected-1, 0)
+		case 14: // Ctrl-N
+			selected = min(selected+1, max(len(filtered)-1, 0))
+		case 127, 8: // Backspace
+			if len(query) > 0 {
+				query = query[:len(query)-1]
+			}
+		case 21: // Ctrl-U
+			query = nil
+		default:
+			if unicode.IsPrint(r) {
+				query = append(query, r)
+				selected = 0
+			}
+		}
+	}
+}
+
+// drawPicker draws the query and the visible targets, marking the selected
+// one, and returns the number of lines drawn.
+func drawPicker(out io.Writer, query string, targets []pickerTarget, selected int) int {
+	fmt.Fprintf(out, "> %s\n", query)
+	first := max(selected-maxPickerRows+1, 0)
+	last := min(first+maxPickerRows, len(targets))
+	for i := first; i < last; i++ {
+		marker := "  "
+		if i == selected {
+			marker = "\x1b[7m>"
+		}
+		fmt.Fprintf(out, "%s %-20s \x1b[2m%s\x1b[0m\n", marker, targets[i].Label, targets[i].URL)
+	}
+	if len(targets) == 0 {
+		fmt.Fprintln(out, "  no matches")
+		return 2
+	}
+	return 1 + last - first
+}
+
+// clearLines moves the cursor up over the n lines last drawn and clears them.
+func clearLines(out io.Writer, n int) {
+	if n > 0 {
+		fmt.Fprintf(out, "\r\x1b[%dA\x1b[J", n)
+	}
+}
+
+// numberedPick lists the targets with numbers and reads the number of the one
+// to open. Any other input filters the list.
+func numberedPick(in *bufio.Reader, out io.Writer, targets []pickerTarget) (pickerTarget, error) {
+	filtered := targets
+	for {
+		shown := filtered[:min(len(filtered), maxNumberedRows)]
+		for i, target := range shown {
+			fmt.Fprintf(out, "%2d) %-20s %s\n", i+1, target.Label, target.URL)
+		}
+		if more := len(filtered) - len(shown); more > 0 {
+			fmt.Fprintf(out, "    ... and %d more\n", more)
+		}
+		fmt.Fprintf(out, "Open [1-%d, or text to filter]: ", len(shown))
+
+		line, err := in.ReadString('\n')
+		answer := strings.TrimSpace(line)
+		if answer == "" {
+			if err != nil {
+				fmt.Fprintln(out)
+			}
+			return pickerTarget{}, errNoSelection
+		}
+		if n, convErr := strconv.Atoi(answer); convErr == nil && n >= 1 && n <= len(shown) {
+			return shown[n-1], nil
+		}
+		if matches := filterTargets(targets, answer); len(matches) > 0 {
+			filtered = matches
+		} else {
+			fmt.Fprintf(out, "No targets match %q\n", answer)
+		}
+		if err != nil {
+			return pickerTarget{}, errNoSelection
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5/config"
+	"github.com/spf13/cobra"
+	"github.com/zhaochunqi/git-open/internal/testhelper"
+)
+
+var testTargets = []pickerTarget{
+	{"home", "https://github.com/owner/repo"},
+	{"pull requests", "https://github.com/owner/repo/pulls"},
+	{"ci", "https://github.com/owner/repo/actions"},
+	{"releases", "https://github.com/owner/repo/releases"},
+}
+
+func Test_fuzzyMatch(t *testing.T) {
+	tests := []struct {
+		query, text string
+		wantScore   int
+		wantOK      bool
+	}{
+		{"", "home", 0, true},
+		{"rel", "releases", 0, true},
+		{"PR", "pull requests", 4, true},
+		{"rq", "pull requests", 6, true},
+		{"xyz", "home", 0, false},
+		{"eh", "home", 0, false},
+	}
+	for _, tt := range tests {
+		score, ok := fuzzyMatch(tt.query, tt.text)
+		if ok != tt.wantOK || (ok && score != tt.wantScore) {
+			t.Errorf("fuzzyMatch(%q, %q) = %d, %v, want %d, %v", tt.query, tt.text, score, ok, tt.wantScore, tt.wantOK)
+		}
+	}
+}
+
+func Test_filterTargets(t *testing.T) {
+	var labels []string
+	for _, target := range filterTargets(testTargets, "e") {
+		labels = append(labels, target.Label)
+	}
+	if want := []string{"releases", "home", "pull requests"}; !reflect.DeepEqual(labels, want) {
+		t.Errorf("filterTargets() = %q, want %q", labels, want)
+	}
+}
+
+func Test_fuzzyPick(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr error
+	}{
+		{"first target", "\r", "home", nil},
+		{"filter", "rel\r", "releases", nil},
+		{"arrow keys", "\x1b[B\x1b[B\x1b[A\r", "pull requests", nil},
+		{"ctrl-n", "\x0e\x0e\r", "ci", nil},
+		{"backspace", "relx\x7f\x7f\x7f\x7fci\r", "ci", nil},
+		{"no match then enter", "zzz\r", "", errNoSelection},
+		{"ctrl-c", "\x03", "", errNoSelection},
+		{"esc", "\x1b", "", errNoSelection},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := fuzzyPick(bufio.NewReader(strings.NewReader(tt.input)), io.Discard, testTargets)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("fuzzyPick() error = %v, want %v", err, tt.wantErr)
+			}
+			if got.Label != tt.want {
+				t.Errorf("fuzzyPick() = %q, want %q", got.Label, tt.want)
+			}
+		})
+	}
+}
+
+func Test_pickTarget_NumberedPrompt(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr error
+	}{
+		{"number", "3\n", "ci", nil},
+		{"filter then number", "rel\n1\n", "releases", nil},
+		{"no match keeps the list", "zzz\n2\n", "pull requests", nil},
+		{"out of range filters", "9\n1\n", "home", nil},
+		{"empty answer", "\n", "", errNoSelection},
+		{"end of input", "", "", errNoSelection},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := new(bytes.Buffer)
+			got, err := pickTarget(strings.NewReader(tt.input), out, testTargets)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("pickTarget() error = %v, want %v", err, tt.wantErr)
+			}
+			if got.Label != tt.want {
+				t.Errorf("pickTarget() = %q, want %q", got.Label, tt.want)
+			}
+			if !strings.Contains(out.String(), " 1) home") {
+				t.Errorf("pickTarget() output = %q, want a numbered list", out.String())
+			}
+		})
+	}
+}
+
+func Test_interactiveTargets(t *testing.T) {
+	_, cleanup := testhelper.SetupTestRepo(t, "git@github.com:owner/repo.git", "feature")
+	defer cleanup()
+
+	originalLinks, originalDocs := Links, DocsTemplate
+	t.Cleanup(func() { Links, DocsTemplate = originalLinks, originalDocs })
+	Links = map[string]string{"grafana": "https://grafana.example.com/d/{{.Repo}}"}
+	DocsTemplate = ""
+
+	repo, err := getCurrentGitDirectory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.CreateRemote(&config.RemoteConfig{Name: "upstream", URLs: []string{"https://gitlab.com/group/repo.git"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	resolved, err := resolveRepositoryWebURL(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := interactiveTargets(resolved)
+	want := []pickerTarget{
+		{"home", "https://github.com/owner/repo"},
+		{"branch feature", "https://github.com/owner/repo/tree/feature"},
+		{"pull requests", "https://github.com/owner/repo/pulls"},
+		{"ci", "https://github.com/owner/repo/actions"},
+		{"issues", "https://github.com/owner/repo/issues"},
+		{"releases", "https://github.com/owner/repo/releases"},
+		{"remote upstream", "https://gitlab.com/group/repo"},
+		{"link grafana", "https://grafana.example.com/d/repo"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("interactiveTargets() =\n%v\nwant\n%v", got, want)
+	}
+}
+
+func Test_rootCmd_Interactive(t *testing.T) {
+	_, cleanup := testhelper.SetupTestRepo(t, "https://gitlab.com/group/repo.git", "main")
+	defer cleanup()
+
+	original := pickTargetFunc
+	t.Cleanup(func() { pickTargetFunc = original })
+	pickTargetFunc = func(in io.Reader, out io.Writer, targets []pickerTarget) (pickerTarget, error) {
+		for _, target := range targets {
+			if target.Label == "ci" {
+				return target, nil
+			}
+		}
+		return pickerTarget{}, errNoSelection
+	}
+
+	buf := new(bytes.Buffer)
+	cmd := &cobra.Command{}
+	cmd.SetOut(buf)
+	cmd.Flags().Bool("plain", true, "")
+	cmd.Flags().Bool("interactive", true, "")
+	if err := rootCmd.RunE(cmd, []string{}); err != nil {
+		t.Fatalf("rootCmd.RunE() error = %v", err)
+	}
+	if want := "Web URL: https://gitlab.com/group/repo/-/pipelines\n"; buf.String() != want {
+		t.Errorf("rootCmd output = %q, want %q", buf.String(), want)
+	}
+}
+
+func Test_numberedPick_ManyTargets(t *testing.T) {
+	var targets []pickerTarget
+	for i := 0; i < maxNumberedRows+10; i++ {
+		targets = append(targets, pickerTarget{Label: fmt.Sprintf("file%02d.go", i)})
+	}
+
+	out := new(bytes.Buffer)
+	got, err := numberedPick(bufio.NewReader(strings.NewReader("55\nfile55\n1\n")), out, targets)
+	if err != nil {
+		t.Fatalf("numberedPick() error = %v", err)
+	}
+	if got.Label != "file55.go" {
+		t.Errorf("numberedPick() = %q, want file55.go", got.Label)
+	}
+	if !strings.Contains(out.String(), "... and 10 more") {
+		t.Errorf("numberedPick() output = %q, want the list cut short", out.String())
+	}
+}
@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/viper"
+	"github.com/zhaochunqi/git-open/pkg/gitopen"
+)
+
+// projectConfigName is the name of the project config checked in at the root
+// of a repository.
+const projectConfigName = ".git-open.yaml"
+
+// projectConfigKeys are the top-level keys a project config may set. Keys that
+// run commands, like browser, are left out so that cloning a repository cannot
+// make git-open run anything.
+var projectConfigKeys = map[string]bool{
+	"remote":        true,
+	"provider":      true,
+	"defaultbranch": true,
+	"weburl":        true,
+	"issues":        true,
+	"docs":          true,
+	"links":         true,
+	"formats":       true,
+}
+
+// projectCommonDir is the common git directory of the repository the project
+// config was loaded from, empty when there is none.
+var projectCommonDir string
+
+// projectConfigFile is the path of the project config in use, empty when
+// there is none.
+var projectConfigFile string
+
+// projectConfigProblems are the problems with the project config, such as
+// keys it may not set, which doctor reports.
+var projectConfigProblems []string
+
+// projectSettings are the repository settings from the project config. Unlike
+// the rest of the project config, which is merged beneath the user config,
+// they only apply to the repository the project config belongs to.
+var projectSettings repoSettings
+
+// startDir returns the directory git-open runs in once the -C paths are
+// applied, without changing to it.
+func startDir() string {
+	dir := "."
+	for _, path := range chdirPaths {
+		if filepath.IsAbs(path) {
+			dir = path
+		} else {
+			dir = filepath.Join(dir, path)
+		}
+	}
+	return dir
+}
+
+// loadProjectConfig reads the project config at the root of the repository
+// containing dir, if any, and merges it beneath the user config. Keys it may
+// not set are traced and left to doctor to report.
+func loadProjectConfig(dir string) error {
+	projectCommonDir, projectConfigFile, projectSettings = "", "", repoSettings{}
+	projectConfigProblems = nil
+
+	// Bare repositories have no checked-in files
+	gitDir, workTree, err := findStartRepository(dir)
+	if err != nil || workTree == "" {
+		return nil
+	}
+	path := filepath.Join(workTree, projectConfigName)
+	if _, err := os.Stat(path); err != nil {
+		return nil
+	}
+	commonDir, err := gitopen.CommonGitDir(gitDir)
+	if err != nil {
+		return err
+	}
+
+	project := viper.New()
+	project.SetConfigFile(path)
+	if err := project.ReadInConfig(); err != nil {
+		return fmt.Errorf("error reading project config: %w", err)
+	}
+	logger.Debug("using project config file", "path", path)
+
+	for _, key := range project.AllKeys() {
+		top, _, _ := strings.Cut(key, ".")
+		if !projectConfigKeys[top] {
+			logger.Warn("ignoring project config key", "key", key, "path", path)
+			projectConfigProblems = append(projectConfigProblems, fmt.Sprintf("%q ignored: it can only be set in the user config", key))
+			continue
+		}
+		switch key {
+		case "remote":
+			projectSettings.Remote = strings.TrimSpace(project.GetString(key))
+		case "provider":
+			projectSettings.Provider = strings.TrimSpace(project.GetString(key))
+		case "defaultbranch":
+			projectSettings.DefaultBranch = strings.TrimSpace(project.GetString(key))
+		case "weburl":
+			projectSettings.WebURL = strings.TrimSuffix(strings.TrimSpace(project.GetString(key)), "/")
+		default:
+			viper.SetDefault(key, project.Get(key))
+		}
+	}
+	projectCommonDir, projectConfigFile = commonDir, path
+	return nil
+}
+
+// stringMapSetting returns the string map under key, merging the user config
+// with the defaults set from the project config, which viper.GetStringMapString
+// does not.
+func stringMapSetting(key string) map[string]string {
+	prefix := strings.ToLower(key) + "."
+	m := make(map[string]string)
+	for _, k := range viper.AllKeys() {
+		if name, ok := strings.CutPrefix(k, prefix); ok {
+			m[name] = viper.GetString(k)
+		}
+	}
+	return m
+}
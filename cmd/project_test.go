@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/zhaochunqi/git-open/internal/testhelper"
+)
+
+// withProjectConfig writes a project config to the repository in dir and
+// reads it together with the user config in userConfig, like initConfig does.
+func withProjectConfig(t *testing.T, dir, projectConfig, userConfig string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, projectConfigName), []byte(projectConfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+	userFile := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(userFile, []byte(userConfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	originalCfgFile, originalBrowser, originalFormats := cfgFile, BrowserCommand, Formats
+	originalIssues, originalDocs := IssueTemplate, DocsTemplate
+	originalLinks, originalHostLinks := Links, HostLinks
+	t.Cleanup(func() {
+		cfgFile, BrowserCommand, Formats = originalCfgFile, originalBrowser, originalFormats
+		IssueTemplate, DocsTemplate = originalIssues, originalDocs
+		Links, HostLinks = originalLinks, originalHostLinks
+		projectCommonDir, projectConfigFile, projectSettings = "", "", repoSettings{}
+		projectConfigProblems = nil
+		startRepository.dir, startRepository.gitDir, startRepository.workTree = "", "", ""
+		viper.Reset()
+	})
+	viper.Reset()
+	cfgFile = userFile
+	initConfig()
+}
+
+func Test_loadProjectConfig(t *testing.T) {
+	dir, cleanup := testhelper.SetupTestRepo(t, "https://github.com/owner/repo.git", "main")
+	defer cleanup()
+
+	withProjectConfig(t, dir, `
+browser: curl
+provider: gitlab
+issues: https://jira.example.com/browse/{{.Issue}}
+formats:
+  short: "{{.Repo}}"
+  md: "project"
+`, `
+formats:
+  md: "user"
+`)
+
+	if BrowserCommand != "" {
+		t.Errorf("BrowserCommand = %q, want the project config's browser ignored", BrowserCommand)
+	}
+	if want := []string{`"browser" ignored: it can only be set in the user config`}; len(projectConfigProblems) != 1 || projectConfigProblems[0] != want[0] {
+		t.Errorf("projectConfigProblems = %q, want %q", projectConfigProblems, want)
+	}
+	if IssueTemplate != "https://jira.example.com/browse/{{.Issue}}" {
+		t.Errorf("IssueTemplate = %q, want the project config's", IssueTemplate)
+	}
+	if Formats["short"] != "{{.Repo}}" || Formats["md"] != "user" {
+		t.Errorf("Formats = %v, want the project formats beneath the user ones", Formats)
+	}
+
+	repo, err := openFastRepository(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if settings, _ := repo.settings(); settings.Provider != "gitlab" {
+		t.Errorf("settings().Provider = %q, want the project config's", settings.Provider)
+	}
+
+	// Other repositories, e.g. those visited by each, keep their own settings
+	other, otherCleanup := testhelper.SetupTestRepo(t, "https://github.com/owner/other.git", "main")
+	defer otherCleanup()
+	otherRepo, err := openFastRepository(other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if settings, _ := otherRepo.settings(); settings.Provider != "" {
+		t.Errorf("settings().Provider = %q for another repository, want none", settings.Provider)
+	}
+}
+
+func Test_rootCmd_ProjectWebURL(t *testing.T) {
+	dir, cleanup := testhelper.SetupTestRepo(t, "/srv/mirrors/repo.git", "main")
+	defer cleanup()
+	withProjectConfig(t, dir, "webURL: https://github.com/owner/repo/\n", "")
+
+	buf := new(bytes.Buffer)
+	cmd := &cobra.Command{}
+	cmd.SetOut(buf)
+	cmd.Flags().Bool("plain", true, "")
+	if err := rootCmd.RunE(cmd, []string{}); err != nil {
+		t.Fatalf("rootCmd.RunE() error = %v", err)
+	}
+	if want := "Web URL: https://github.com/owner/repo\n"; buf.String() != want {
+		t.Errorf("rootCmd output = %q, want %q", buf.String(), want)
+	}
+}
+
+func Test_issueCmd(t *testing.T) {
+	tests := []struct {
+		name      string
+		remoteURL string
+		template  string
+		args      []string
+		want      string
+		wantErr   bool
+	}{
+		{"GitHub issue", "git@github.com:owner/repo.git", "", []string{"#42"}, "https://github.com/owner/repo/issues/42", false},
+		{"GitHub issue list", "git@github.com:owner/repo.git", "", nil, "https://github.com/owner/repo/issues", false},
+		{"GitLab issue", "https://gitlab.com/group/repo.git", "", []string{"7"}, "https://gitlab.com/group/repo/-/issues/7", false},
+		{"template", "git@github.com:owner/repo.git", "https://jira.example.com/browse/{{.Issue}}", []string{"OPS-1"}, "https://jira.example.com/browse/OPS-1", false},
+		{"template with fields", "git@github.com:owner/repo.git", "https://tracker.example.com/{{.Repo}}/{{.Issue}}", []string{"3"}, "https://tracker.example.com/repo/3", false},
+		{"unknown tracker", "https://code.example.com/owner/repo.git", "", nil, "", true},
+	}
+
+	original := IssueTemplate
+	t.Cleanup(func() { IssueTemplate = original })
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, cleanup := testhelper.SetupTestRepo(t, tt.remoteURL, "main")
+			defer cleanup()
+			IssueTemplate = tt.template
+
+			buf := new(bytes.Buffer)
+			cmd := &cobra.Command{}
+			cmd.SetOut(buf)
+			cmd.Flags().Bool("plain", true, "")
+			err := issueCmd.RunE(cmd, tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("issueCmd.RunE() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if want := "Web URL: " + tt.want + "\n"; !tt.wantErr && buf.String() != want {
+				t.Errorf("issueCmd output = %q, want %q", buf.String(), want)
+			}
+		})
+	}
+}
+
+func Test_docsCmd(t *testing.T) {
+	_, cleanup := testhelper.SetupTestRepo(t, "git@github.com:owner/repo.git", "main")
+	defer cleanup()
+
+	original := DocsTemplate
+	t.Cleanup(func() { DocsTemplate = original })
+
+	run := func() (string, error) {
+		buf := new(bytes.Buffer)
+		cmd := &cobra.Command{}
+		cmd.SetOut(buf)
+		cmd.Flags().Bool("plain", true, "")
+		err := docsCmd.RunE(cmd, nil)
+		return buf.String(), err
+	}
+
+	DocsTemplate = ""
+	if _, err := run(); err == nil {
+		t.Error("docsCmd.RunE() expected error without a docs URL, got nil")
+	}
+
+	DocsTemplate = "https://docs.example.com/{{.Owner}}/{{.Repo}}"
+	if got, err := run(); err != nil || got != "Web URL: https://docs.example.com/owner/repo\n" {
+		t.Errorf("docsCmd.RunE() = %q, %v, want the rendered docs URL", got, err)
+	}
+}
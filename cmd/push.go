@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/spf13/cobra"
+)
+
+// addPushFlags adds the flags controlling how links to unpushed commits are
+// handled to c.
+func addPushFlags(c *cobra.Command) {
+	c.Flags().Bool("strict", false, "Fail instead of warning when the branch or commit has not been pushed.")
+	c.Flags().Bool("use-remote-commit", false, "Link to the last pushed commit of HEAD instead of the branch.")
+}
+
+// linkRef returns the ref that links into repo use: branchName, or the commit
+// HEAD points to when branchName is empty (a detached HEAD). It warns when
+// that is not on remote, since the link would not work for anyone else, or
+// fails with --strict. With --use-remote-commit the link is pinned to the last
+// pushed commit instead.
+func linkRef(cmd *cobra.Command, repo *git.Repository, remote, branchName string) (string, error) {
+	useRemote, _ := cmd.Flags().GetBool("use-remote-commit")
+	head, err := headCommit(repo)
+	if err != nil {
+		// A bare repository's HEAD may name a branch without commits, which
+		// there is nothing to check for
+		if branchName != "" && !useRemote {
+			return branchName, nil
+		}
+		return "", err
+	}
+
+	if useRemote {
+		commit, err := lastPushedCommit(repo, head, remote, branchName)
+		if err != nil {
+			return "", err
+		}
+		return commit.Hash.String(), nil
+	}
+
+	ref := branchName
+	if ref == "" {
+		ref = head.Hash.String()
+	}
+	reason, err := unpushedReason(repo, head, remote, branchName)
+	if err != nil || reason == "" {
+		return ref, err
+	}
+	if strict, _ := cmd.Flags().GetBool("strict"); strict {
+		return "", fmt.Errorf("%s, so the link would not work for others", reason)
+	}
+	fmt.Fprintf(cmd.ErrOrStderr(), "Warning: %s, so the link may not work for others\n", reason)
+	return ref, nil
+}
+
+// headCommit returns the commit HEAD of repo points to.
+func headCommit(repo *git.Repository) (*object.Commit, error) {
+	head, err := repo.Head()
+	if err != nil {
+		return nil, fmt.Errorf("error getting HEAD: %w", err)
+	}
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("error getting HEAD commit: %w", err)
+	}
+	return commit, nil
+}
+
+// unpushedReason explains why head is not on remote, or returns "" when it
+// is. On a branch head must be in the history of its remote-tracking branch;
+// on a detached HEAD (branchName empty), in that of any branch of remote.
+func unpushedReason(repo *git.Repository, head *object.Commit, remote, branchName string) (string, error) {
+	tips, err := remoteTips(repo, remote, branchName)
+	if err != nil {
+		return "", err
+	}
+	if len(tips) == 0 && branchName != "" {
+		return fmt.Sprintf("branch %q has not been pushed to %s", branchName, remote), nil
+	}
+
+	for _, tip := range tips {
+		if tip.Hash == head.Hash {
+			return "", nil
+		}
+		if pushed, err := head.IsAncestor(tip); err != nil {
+			return "", err
+		} else if pushed {
+			return "", nil
+		}
+	}
+	if branchName != "" {
+		return fmt.Sprintf("branch %q is ahead of %s/%s", branchName, remote, branchName), nil
+	}
+	return fmt.Sprintf("commit %s is not on %s", head.Hash.String()[:7], remote), nil
+}
+
+// lastPushedCommit returns the newest commit in the history of head that is
+// on remote: in that of the remote-tracking branch of branchName when there
+// is one, or else in that of any branch of remote.
+func lastPushedCommit(repo *git.Repository, head *object.Commit, remote, branchName string) (*object.Commit, error) {
+	tips, err := remoteTips(repo, remote, branchName)
+	if err != nil {
+		return nil, err
+	}
+	if len(tips) == 0 {
+		if tips, err = remoteTips(repo, remote, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	var last *object.Commit
+	for _, tip := range tips {
+		bases, err := head.MergeBase(tip)
+		if err != nil {
+			return nil, err
+		}
+		for _, base := range bases {
+			if last == nil || base.Committer.When.After(last.Committer.When) {
+				last = base
+			}
+		}
+	}
+	if last == nil {
+		return nil, fmt.Errorf("no commit of HEAD has been pushed to %s", remote)
+	}
+	return last, nil
+}
+
+// remoteTips returns the commits the remote-tracking branches of remote point
+// to: only that of branchName unless it is empty.
+func remoteTips(repo *git.Repository, remote, branchName string) ([]*object.Commit, error) {
+	if branchName != "" {
+		ref, err := repo.Reference(plumbing.NewRemoteReferenceName(remote, branchName), true)
+		if errors.Is(err, plumbing.ErrReferenceNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		commit, err := repo.CommitObject(ref.Hash())
+		if err != nil {
+			return nil, err
+		}
+		return []*object.Commit{commit}, nil
+	}
+
+	refs, err := repo.References()
+	if err != nil {
+		return nil, err
+	}
+	defer refs.Close()
+
+	var tips []*object.Commit
+	prefix := "refs/remotes/" + remote + "/"
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		if ref.Type() != plumbing.HashReference || !strings.HasPrefix(ref.Name().String(), prefix) {
+			return nil
+		}
+		if commit, err := repo.CommitObject(ref.Hash()); err == nil {
+			tips = append(tips, commit)
+		}
+		return nil
+	})
+	return tips, err
+}
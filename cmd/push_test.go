@@ -0,0 +1,248 @@
+package cmd
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/spf13/cobra"
+	"github.com/zhaochunqi/git-open/internal/testhelper"
+)
+
+// commitEmpty adds an empty commit to the current branch of repo.
+func commitEmpty(t *testing.T, repo *git.Repository, when time.Time) plumbing.Hash {
+	t.Helper()
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash, err := wt.Commit("change", &git.CommitOptions{
+		Author:            &object.Signature{Name: "Test", Email: "test@example.com", When: when},
+		AllowEmptyCommits: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hash
+}
+
+func Test_rootCmd_UnpushedBranch(t *testing.T) {
+	tests := []struct {
+		name            string
+		pushed          string // "", "head" or "parent"
+		strict          bool
+		useRemoteCommit bool
+		wantURL         string
+		wantWarning     string
+		wantErr         bool
+	}{
+		{name: "pushed", pushed: "head", wantURL: "https://github.com/owner/repo/tree/feature"},
+		{name: "no upstream", wantURL: "https://github.com/owner/repo/tree/feature", wantWarning: `branch "feature" has not been pushed to origin`},
+		{name: "no upstream with --strict", strict: true, wantErr: true},
+		{name: "ahead", pushed: "parent", wantURL: "https://github.com/owner/repo/tree/feature", wantWarning: `branch "feature" is ahead of origin/feature`},
+		{name: "ahead with --strict", pushed: "parent", strict: true, wantErr: true},
+		{name: "ahead with --use-remote-commit", pushed: "parent", useRemoteCommit: true, wantURL: "https://github.com/owner/repo/tree/PARENT"},
+		{name: "pushed with --use-remote-commit", pushed: "head", useRemoteCommit: true, wantURL: "https://github.com/owner/repo/tree/HEAD"},
+		{name: "no upstream with --use-remote-commit", useRemoteCommit: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, cleanup := testhelper.SetupTestRepo(t, "git@github.com:owner/repo.git", "feature")
+			defer cleanup()
+
+			repo, err := getCurrentGitDirectory()
+			if err != nil {
+				t.Fatal(err)
+			}
+			parent, err := repo.Head()
+			if err != nil {
+				t.Fatal(err)
+			}
+			head := commitEmpty(t, repo, time.Now())
+			tracking := plumbing.NewRemoteReferenceName("origin", "feature")
+			switch tt.pushed {
+			case "head":
+				err = repo.Storer.SetReference(plumbing.NewHashReference(tracking, head))
+			case "parent":
+				err = repo.Storer.SetReference(plumbing.NewHashReference(tracking, parent.Hash()))
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			out, errOut := new(bytes.Buffer), new(bytes.Buffer)
+			cmd := &cobra.Command{}
+			cmd.SetOut(out)
+			cmd.SetErr(errOut)
+			cmd.Flags().Bool("plain", true, "")
+			addPushFlags(cmd)
+			cmd.Flags().Set("strict", strconv.FormatBool(tt.strict))
+			cmd.Flags().Set("use-remote-commit", strconv.FormatBool(tt.useRemoteCommit))
+
+			err = rootCmd.RunE(cmd, []string{})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("rootCmd.RunE() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			wantURL := strings.NewReplacer("PARENT", parent.Hash().String(), "HEAD", head.String()).Replace(tt.wantURL)
+			if want := "Web URL: " + wantURL + "\n"; out.String() != want {
+				t.Errorf("rootCmd output = %q, want %q", out.String(), want)
+			}
+			if tt.wantWarning == "" && errOut.Len() > 0 {
+				t.Errorf("rootCmd warned %q, want no warning", errOut.String())
+			}
+			if !strings.Contains(errOut.String(), tt.wantWarning) {
+				t.Errorf("rootCmd warning = %q, want %q", errOut.String(), tt.wantWarning)
+			}
+		})
+	}
+}
+
+func Test_fastRepository_pushed(t *testing.T) {
+	_, cleanup := testhelper.SetupTestRepo(t, "git@github.com:owner/repo.git", "feature")
+	defer cleanup()
+
+	repo, err := getCurrentGitDirectory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fast, err := openFastRepository(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fast.pushed("origin", "feature") {
+		t.Error("pushed() = true without a remote-tracking branch, want false")
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Storer.SetReference(plumbing.NewHashReference(plumbing.NewRemoteReferenceName("origin", "feature"), head.Hash())); err != nil {
+		t.Fatal(err)
+	}
+	if !fast.pushed("origin", "feature") {
+		t.Error("pushed() = false with the remote-tracking branch at HEAD, want true")
+	}
+}
+
+func Test_linkRef_DetachedHead(t *testing.T) {
+	_, cleanup := testhelper.SetupTestRepo(t, "git@github.com:owner/repo.git", "main")
+	defer cleanup()
+
+	repo, err := getCurrentGitDirectory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pushed, err := repo.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Storer.SetReference(plumbing.NewHashReference(plumbing.NewRemoteReferenceName("origin", "main"), pushed.Hash())); err != nil {
+		t.Fatal(err)
+	}
+	local := commitEmpty(t, repo, time.Now())
+	if err := repo.Storer.SetReference(plumbing.NewHashReference(plumbing.HEAD, local)); err != nil {
+		t.Fatal(err)
+	}
+
+	errOut := new(bytes.Buffer)
+	cmd := &cobra.Command{}
+	cmd.SetErr(errOut)
+	addPushFlags(cmd)
+
+	if ref, err := linkRef(cmd, repo, "origin", ""); err != nil || ref != local.String() {
+		t.Errorf("linkRef() = %q, %v, want the detached commit", ref, err)
+	}
+	if !strings.Contains(errOut.String(), "is not on origin") {
+		t.Errorf("linkRef() warning = %q, want the commit reported as not pushed", errOut.String())
+	}
+
+	cmd.Flags().Set("use-remote-commit", "true")
+	if ref, err := linkRef(cmd, repo, "origin", ""); err != nil || ref != pushed.Hash().String() {
+		t.Errorf("linkRef() = %q, %v, want the last pushed commit %s", ref, err, pushed.Hash())
+	}
+}
+
+func Test_rootCmd_UnpushedTag(t *testing.T) {
+	tests := []struct {
+		name            string
+		pushed          bool
+		strict          bool
+		useRemoteCommit bool
+		wantURL         string
+		wantWarning     string
+		wantErr         bool
+	}{
+		{name: "pushed", pushed: true, strict: true, wantURL: "https://github.com/owner/repo/releases/tag/v1.0.0"},
+		{name: "pushed with --use-remote-commit", pushed: true, useRemoteCommit: true, wantURL: "https://github.com/owner/repo/releases/tag/v1.0.0"},
+		{name: "not pushed", wantURL: "https://github.com/owner/repo/releases/tag/v1.0.0", wantWarning: "is not on origin"},
+		{name: "not pushed with --strict", strict: true, wantErr: true},
+		{name: "not pushed with --use-remote-commit", useRemoteCommit: true, wantURL: "https://github.com/owner/repo/tree/PARENT"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, cleanup := testhelper.SetupTestRepo(t, "git@github.com:owner/repo.git", "main")
+			defer cleanup()
+
+			repo, err := getCurrentGitDirectory()
+			if err != nil {
+				t.Fatal(err)
+			}
+			parent, err := repo.Head()
+			if err != nil {
+				t.Fatal(err)
+			}
+			head := commitEmpty(t, repo, time.Now())
+			pushed := parent.Hash()
+			if tt.pushed {
+				pushed = head
+			}
+			if err := repo.Storer.SetReference(plumbing.NewHashReference(plumbing.NewRemoteReferenceName("origin", "main"), pushed)); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := repo.CreateTag("v1.0.0", head, nil); err != nil {
+				t.Fatal(err)
+			}
+			detachHead(t, repo, head)
+
+			out, errOut := new(bytes.Buffer), new(bytes.Buffer)
+			cmd := &cobra.Command{}
+			cmd.SetOut(out)
+			cmd.SetErr(errOut)
+			cmd.Flags().Bool("plain", true, "")
+			addPushFlags(cmd)
+			cmd.Flags().Set("strict", strconv.FormatBool(tt.strict))
+			cmd.Flags().Set("use-remote-commit", strconv.FormatBool(tt.useRemoteCommit))
+
+			err = rootCmd.RunE(cmd, []string{})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("rootCmd.RunE() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			wantURL := strings.Replace(tt.wantURL, "PARENT", parent.Hash().String(), 1)
+			if want := "Web URL: " + wantURL + "\n"; out.String() != want {
+				t.Errorf("rootCmd output = %q, want %q", out.String(), want)
+			}
+			if tt.wantWarning == "" && errOut.Len() > 0 {
+				t.Errorf("rootCmd warned %q, want no warning", errOut.String())
+			}
+			if !strings.Contains(errOut.String(), tt.wantWarning) {
+				t.Errorf("rootCmd warning = %q, want %q", errOut.String(), tt.wantWarning)
+			}
+		})
+	}
+}
@@ -14,13 +14,30 @@ var repoCmd = &cobra.Command{
 	Long: `Print the name of the Git repository in the current working directory,
 in the form of host/owner/repo (e.g. github.com/zhaochunqi/git-open).`,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		format, _ := cmd.Flags().GetString("format")
+		var fast *fastRepository
+		if format == "" {
+			resolved, ok := fastResolveWebURLFunc()
+			if ok {
+				fmt.Fprintln(cmd.OutOrStdout(), repoNameFromWebURL(resolved.webURL))
+				return nil
+			}
+			if resolved != nil {
+				fast = resolved.repo
+			}
+		}
+
 		// Get the repository name from the web URL of the remote
-		_, _, webURL, err := resolveWebURL()
+		resolved, err := resolveFastRepositoryWebURL(fast)
 		if err != nil {
 			return err
 		}
 
-		fmt.Fprintln(cmd.OutOrStdout(), repoNameFromWebURL(webURL))
+		if format != "" {
+			return printTarget(cmd.OutOrStdout(), format, resolved.homeTarget())
+		}
+
+		fmt.Fprintln(cmd.OutOrStdout(), repoNameFromWebURL(resolved.target.HomeURL))
 		return nil
 	},
 }
@@ -34,5 +51,6 @@ func repoNameFromWebURL(webURL string) string {
 }
 
 func init() {
+	repoCmd.Flags().String("format", "", formatFlagUsage)
 	rootCmd.AddCommand(repoCmd)
 }
@@ -63,13 +63,14 @@ func Test_repoCmd(t *testing.T) {
 }
 
 func Test_repoCmd_InvalidRemoteURLFormat(t *testing.T) {
+	withoutFastPath(t)
 	originalGetRemoteURLFunc := getRemoteURLFunc
 	defer func() { getRemoteURLFunc = originalGetRemoteURLFunc }()
 
 	_, cleanup := testhelper.SetupTestRepo(t, "https://github.com/test/repo.git", "main")
 	defer cleanup()
 
-	getRemoteURLFunc = func(repo *git.Repository) (string, error) {
+	getRemoteURLFunc = func(repo *git.Repository, fast *fastRepository, name string) (string, error) {
 		return "invalid-remote", nil
 	}
 
@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/spf13/cobra"
+)
+
+// editorRunner runs the user's editor attached to the terminal, can be mocked
+// for testing
+var editorRunner = func(name string, args ...string) error {
+	cmd := exec.Command(name, args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// resolveCmd represents the resolve command
+var resolveCmd = &cobra.Command{
+	Use:   "resolve <web-url>",
+	Short: "Print the local path of a repository web URL",
+	Long: `Map a repository web URL (a repository, file with an optional line anchor such
+as #L10, or commit page) to the matching path in a local clone, and print it as
+path:line. Local clones are found by their remote URLs, in the repository in the
+current working directory or below the directories listed under "workspaces" in
+the config file.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		location, err := parseWebURL(args[0])
+		if err != nil {
+			return err
+		}
+
+		root, repo, err := findLocalClone(location, Workspaces)
+		if err != nil {
+			return err
+		}
+
+		path, err := localPathForLocation(root, repo, location)
+		if err != nil {
+			return err
+		}
+
+		edit, _ := cmd.Flags().GetBool("edit")
+		if edit {
+			return openInEditor(path, location.Line)
+		}
+
+		if location.Line > 0 {
+			fmt.Fprintf(cmd.OutOrStdout(), "%s:%d\n", path, location.Line)
+		} else {
+			fmt.Fprintln(cmd.OutOrStdout(), path)
+		}
+		return nil
+	},
+}
+
+// localPathForLocation returns the path in the working tree of repo at root
+// that location refers to. Refs may contain slashes, so the ref is taken to be
+// the longest prefix of location.Rest naming a branch, tag or commit of repo,
+// and the rest is the file path. For refs repo does not have, the file path is
+// taken to start at the first split that names an existing file.
+func localPathForLocation(root string, repo *git.Repository, location *webLocation) (string, error) {
+	if location.Kind != pathLocation {
+		return root, nil
+	}
+
+	rest := location.Rest
+	for i := len(rest); i >= 1; i-- {
+		if ref := strings.Join(rest[:i], "/"); ref != "" && !hasRef(repo, ref) {
+			continue
+		}
+		path, err := pathBelowRoot(root, rest[i:])
+		if err != nil {
+			return "", err
+		}
+		if _, err := os.Stat(path); err != nil {
+			return "", fmt.Errorf("no file %s in %s", filepath.Join(rest[i:]...), root)
+		}
+		return path, nil
+	}
+
+	for i := 1; i < len(rest); i++ {
+		path, err := pathBelowRoot(root, rest[i:])
+		if err != nil {
+			return "", err
+		}
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("no file matching %s in %s", filepath.Join(rest[1:]...), root)
+}
+
+// hasRef reports whether name is a branch, remote-tracking branch, tag or
+// commit of repo.
+func hasRef(repo *git.Repository, name string) bool {
+	if _, err := repo.ResolveRevision(plumbing.Revision(name)); err == nil {
+		return true
+	}
+	remotes, err := repo.Remotes()
+	if err != nil {
+		return false
+	}
+	for _, remote := range remotes {
+		if _, err := repo.Reference(plumbing.NewRemoteReferenceName(remote.Config().Name, name), false); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// pathBelowRoot joins segments to root, refusing paths that lead out of it.
+func pathBelowRoot(root string, segments []string) (string, error) {
+	path := filepath.Join(append([]string{root}, segments...)...)
+	if rel, err := filepath.Rel(root, path); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is outside of %s", path, root)
+	}
+	return path, nil
+}
+
+// openInEditor opens path in $EDITOR, passing "+line" to jump to line.
+func openInEditor(path string, line int) error {
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		return errors.New("EDITOR is not set")
+	}
+	words, err := splitCommandLine(editor)
+	if err != nil || len(words) == 0 {
+		return fmt.Errorf("invalid EDITOR %q", editor)
+	}
+
+	args := words[1:]
+	if line > 0 {
+		args = append(args, "+"+strconv.Itoa(line))
+	}
+	args = append(args, path)
+	return editorRunner(words[0], args...)
+}
+
+func init() {
+	resolveCmd.Flags().BoolP("edit", "e", false, "Open the file in $EDITOR instead of printing its path.")
+	rootCmd.AddCommand(resolveCmd)
+}
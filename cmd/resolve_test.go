@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/spf13/cobra"
+	"github.com/zhaochunqi/git-open/internal/testhelper"
+)
+
+func Test_resolveCmd(t *testing.T) {
+	repoDir, cleanup := testhelper.SetupTestRepo(t, "git@github.com:zhaochunqi/git-open.git", "main")
+	defer cleanup()
+	repoDir, err := filepath.EvalSymlinks(repoDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(repoDir, "cmd"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repoDir, "cmd", "git.go"), []byte("package cmd\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// A branch whose name, split at its slash, also names a directory
+	if err := os.MkdirAll(filepath.Join(repoDir, "x", "cmd"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	repo, err := git.PlainOpen(repoDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Storer.SetReference(plumbing.NewHashReference(plumbing.NewBranchReferenceName("feat/x"), head.Hash())); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		url     string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "repository",
+			url:  "https://github.com/zhaochunqi/git-open",
+			want: repoDir + "\n",
+		},
+		{
+			name: "file with line",
+			url:  "https://github.com/zhaochunqi/git-open/blob/main/cmd/git.go#L10",
+			want: filepath.Join(repoDir, "cmd", "git.go") + ":10\n",
+		},
+		{
+			name: "ref with slashes",
+			url:  "https://github.com/zhaochunqi/git-open/blob/feat/open-branch/test.txt",
+			want: filepath.Join(repoDir, "test.txt") + "\n",
+		},
+		{
+			name: "branch with slashes",
+			url:  "https://github.com/zhaochunqi/git-open/tree/feat/x/cmd",
+			want: filepath.Join(repoDir, "cmd") + "\n",
+		},
+		{
+			name: "tree of a branch with slashes",
+			url:  "https://github.com/zhaochunqi/git-open/tree/feat/x",
+			want: repoDir + "\n",
+		},
+		{
+			name: "commit",
+			url:  "https://github.com/zhaochunqi/git-open/commit/0123abc",
+			want: repoDir + "\n",
+		},
+		{
+			name:    "missing file",
+			url:     "https://github.com/zhaochunqi/git-open/blob/main/missing.go",
+			wantErr: true,
+		},
+		{
+			name:    "other repository",
+			url:     "https://github.com/zhaochunqi/other",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			cmd := &cobra.Command{}
+			cmd.SetOut(buf)
+
+			err := resolveCmd.RunE(cmd, []string{tt.url})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolveCmd.RunE() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("resolve output = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_openInEditor(t *testing.T) {
+	original := editorRunner
+	t.Cleanup(func() { editorRunner = original })
+
+	var got []string
+	editorRunner = func(name string, args ...string) error {
+		got = append([]string{name}, args...)
+		return nil
+	}
+
+	t.Setenv("EDITOR", "emacsclient -t")
+	if err := openInEditor("/src/main.go", 12); err != nil {
+		t.Fatalf("openInEditor() error = %v", err)
+	}
+	if want := []string{"emacsclient", "-t", "+12", "/src/main.go"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("openInEditor() ran %q, want %q", got, want)
+	}
+
+	t.Setenv("EDITOR", "vim")
+	if err := openInEditor("/src/main.go", 0); err != nil {
+		t.Fatalf("openInEditor() error = %v", err)
+	}
+	if want := []string{"vim", "/src/main.go"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("openInEditor() ran %q, want %q", got, want)
+	}
+
+	t.Setenv("EDITOR", "")
+	if err := openInEditor("/src/main.go", 1); err == nil {
+		t.Error("openInEditor() expected error without EDITOR, got nil")
+	}
+}
+
+func Test_pathBelowRoot(t *testing.T) {
+	root := filepath.Join(string(filepath.Separator), "src", "repo")
+	if got, err := pathBelowRoot(root, []string{"cmd", "git.go"}); err != nil || got != filepath.Join(root, "cmd", "git.go") {
+		t.Errorf("pathBelowRoot() = %q, %v, want %q", got, err, filepath.Join(root, "cmd", "git.go"))
+	}
+	for _, segments := range [][]string{{".."}, {"cmd", "..", "..", "etc"}, {"..", "repo-other"}} {
+		if got, err := pathBelowRoot(root, segments); err == nil {
+			t.Errorf("pathBelowRoot(%q) = %q, want an error", segments, got)
+		}
+	}
+}
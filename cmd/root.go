@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
@@ -18,7 +20,11 @@ var rootCmd = &cobra.Command{
 	Short: "Print the web URL of the Git repository",
 	Long: `This application retrieves the remote URL of the Git repository in the current working directory
 and converts it to a web URL. The web URL is then printed to the console.`,
+	// Errors are printed by main with a hint, and once the flags are parsed
+	// they are not usage errors
+	SilenceErrors: true,
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
 		for _, path := range chdirPaths {
 			if err := os.Chdir(path); err != nil {
 				return fmt.Errorf("error changing directory to %q: %w", path, err)
@@ -34,34 +40,118 @@ and converts it to a web URL. The web URL is then printed to the console.`,
 			fmt.Fprintf(cmd.OutOrStdout(), "Build Date: %s\n", BuildDate)
 			return nil
 		}
-		// Get the repository, its remote URL, and the converted web URL
-		repo, remoteURL, webURL, err := resolveWebURL()
+		format, _ := cmd.Flags().GetString("format")
+		superproject, _ := cmd.Flags().GetBool("superproject")
+		interactive, _ := cmd.Flags().GetBool("interactive")
+
+		// Opening the current branch only needs the remote URL and HEAD, which
+		// the fast path reads without loading the repository with go-git
+		useRemoteCommit, _ := cmd.Flags().GetBool("use-remote-commit")
+		asJSON, _ := cmd.Flags().GetBool("json")
+		var fast *fastRepository
+		if format == "" && !asJSON && !superproject && !interactive && !useRemoteCommit {
+			resolved, ok := fastResolveWebURLFunc()
+			if resolved != nil {
+				fast = resolved.repo
+			}
+			if !ok {
+				logger.Debug("fast path cannot resolve the repository, using go-git")
+			} else if resolved.detached {
+				logger.Debug("HEAD is detached, using go-git")
+			} else {
+				webURL := resolved.webURL
+				branchPage := resolved.branch != "" && resolved.settings.shouldAppendBranch(resolved.branch)
+				// Whether an unpushed branch is behind its remote-tracking
+				// branch, and the tags of a detached HEAD, take go-git to
+				// find out
+				if !branchPage || resolved.pushed {
+					if branchPage {
+						webURL = resolved.settings.buildBranchURL(webURL, resolved.branch, resolved.remoteURL)
+					}
+					return showWebURL(cmd, webURL, resolved.settings)
+				}
+				logger.Debug("branch is not known to be pushed, using go-git", "branch", resolved.branch)
+			}
+		}
+
+		// Get the repository, its remote URL, and the converted web URL,
+		// reusing what the fast path has read
+		resolve := func() (*resolvedRepository, error) { return resolveFastRepositoryWebURL(fast) }
+		if superproject {
+			resolve = resolveSuperprojectWebURL
+		}
+		resolved, err := resolve()
 		if err != nil {
 			return err
 		}
+		settings, target := resolved.settings, resolved.target
 
-		branchName, err := getBranchName(repo)
-		if err == nil && shouldAppendBranch(branchName) {
-			// For now, we only append branch name if it's not 'main' or 'master'.
-			// This can be improved later to fetch default branch from remote or allow configuration.
-			webURL = buildBranchURL(webURL, branchName, remoteURL)
+		if interactive {
+			picked, err := pickTargetFunc(cmd.InOrStdin(), cmd.OutOrStdout(), interactiveTargets(resolved))
+			if err != nil {
+				return err
+			}
+			return showWebURL(cmd, picked.URL, settings)
 		}
 
-		// Open the web URL in the browser if the -o flag is provided
-		plain, _ := cmd.Flags().GetBool("plain")
-		if plain {
-			fmt.Fprintf(cmd.OutOrStdout(), "Web URL: %s\n", webURL)
-			return nil
+		// The library resolved the page of the branch, or of the tag or
+		// commit of a detached HEAD. Each links a ref that is checked
+		// against the remote, a tag by its commit, or replaced with the last
+		// pushed commit with --use-remote-commit, which turns a tag page
+		// into that commit's page.
+		if target.WebURL != target.HomeURL {
+			ref, err := linkRef(cmd, resolved.repo, settings.Remote, target.Branch)
+			if err != nil {
+				return err
+			}
+			if target.Tag == "" || ref != target.SHA {
+				target.Tag = ""
+				target.WebURL = settings.buildBranchURL(target.HomeURL, ref, target.RemoteURL)
+			}
 		}
 
-		err = openURLInBrowserFunc(webURL)
-		if err != nil {
-			return fmt.Errorf("error opening URL in browser: %w", err)
+		// Print the target as JSON or with a format template instead of
+		// opening it
+		if asJSON {
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			return enc.Encode(target)
 		}
-		return nil
+		if format != "" {
+			return printTarget(cmd.OutOrStdout(), format, target)
+		}
+
+		return showWebURL(cmd, target.WebURL, settings)
 	},
 }
 
+// showWebURL prints webURL with --plain or when there is no display, or opens
+// it in the browser.
+func showWebURL(cmd *cobra.Command, webURL string, settings repoSettings) error {
+	logger.Debug("resolved URL", "url", webURL)
+	plain, _ := cmd.Flags().GetBool("plain")
+	if plain {
+		fmt.Fprintf(cmd.OutOrStdout(), "Web URL: %s\n", webURL)
+		return nil
+	}
+
+	// The repository's git-open.browser beats the YAML browser settings
+	if settings.Browser != "" {
+		command, overrides := BrowserCommand, BrowserOverrides
+		BrowserCommand, BrowserOverrides = settings.Browser, nil
+		defer func() { BrowserCommand, BrowserOverrides = command, overrides }()
+	}
+
+	err := openURLInBrowserFunc(webURL)
+	if errors.Is(err, errHeadless) {
+		return printURLForHeadless(cmd.OutOrStdout(), webURL)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrBrowser, err)
+	}
+	return nil
+}
+
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 var Execute = func() error {
@@ -79,16 +169,27 @@ func init() {
 	// will be global for your application.
 
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.git-open.yaml)")
+	rootCmd.PersistentFlags().BoolVar(&verbose, "verbose", false, "Trace how the URL is resolved to stderr (also enabled by GIT_OPEN_TRACE=1).")
+	rootCmd.PersistentFlags().StringVar(&remoteFlag, "remote", "", "The remote to open, overriding the configured one (default is origin).")
+	rootCmd.RegisterFlagCompletionFunc("remote", completeRemotes)
 	rootCmd.PersistentFlags().StringArrayVarP(&chdirPaths, "chdir", "C", nil, "Run as if git-open was started in <path> instead of the current working directory. May be given multiple times; a non-absolute <path> is relative to the previous one.")
 
 	// Cobra also supports local flags, which will only run
 	// when this action is called directly.
 	rootCmd.Flags().BoolP("plain", "p", false, "Just print the web url without opening.")
 	rootCmd.Flags().BoolP("version", "v", false, "Show version information")
+	rootCmd.Flags().String("format", "", formatFlagUsage)
+	rootCmd.Flags().Bool("superproject", false, "Open the superproject of the submodule in the current directory.")
+	rootCmd.Flags().BoolP("interactive", "i", false, "Pick the page to open (branch, pull requests, CI, issues, releases, remotes, links) from a menu.")
+	rootCmd.Flags().Bool("json", false, "Print the target, including the branch or detached HEAD state, as JSON instead of opening it.")
+	rootCmd.MarkFlagsMutuallyExclusive("interactive", "format", "json")
+	addPushFlags(rootCmd)
 }
 
 // initConfig reads in config file and ENV variables if set.
 func initConfig() {
+	initTracing()
+
 	if cfgFile != "" {
 		// Use config file from the flag.
 		viper.SetConfigFile(cfgFile)
@@ -103,14 +204,53 @@ func initConfig() {
 		viper.SetConfigName(".git-open")
 	}
 
-	viper.AutomaticEnv() // read in environment variables that match
+	bindEnv()
 
 	// If a config file is found, read it in.
 	if err := viper.ReadInConfig(); err == nil {
-		fmt.Fprintln(os.Stderr, "Using config file:", viper.ConfigFileUsed())
+		logger.Debug("using config file", "path", viper.ConfigFileUsed())
+	}
+
+	// Merge the project config of the repository git-open runs in beneath
+	// it. Problems with it are traced, and reported by doctor, rather than
+	// printed by every command.
+	if err := loadProjectConfig(startDir()); err != nil {
+		logger.Warn("cannot read project config", "error", err)
+		projectConfigProblems = append(projectConfigProblems, err.Error())
 	}
 
 	BrowserCommand = strings.TrimSpace(viper.GetString("browser"))
+	RemoteName = viper.GetString("remote")
+	Provider = viper.GetString("provider")
+	DefaultBranch = viper.GetString("defaultBranch")
+	BrowserOverrides = viper.GetStringMapString("browsers")
+	Formats = stringMapSetting("formats")
+	IssueTemplate = viper.GetString("issues")
+	DocsTemplate = viper.GetString("docs")
+	Links = stringMapSetting("links")
+	HostLinks = hostLinksSetting()
+	Workspaces = viper.GetStringSlice("workspaces")
+	CloneRoot = viper.GetString("clone.root")
+	CloneLayout = viper.GetString("clone.layout")
+	PrintHyperlink = viper.GetBool("hyperlink")
+	PrintQRCode = viper.GetBool("qrcode")
+}
+
+// envKeys are the config keys read from the environment, by their upper-cased
+// name with a GIT_OPEN_ prefix, e.g. GIT_OPEN_DEFAULTBRANCH. Maps such as links
+// cannot be set from the environment.
+var envKeys = []string{
+	"remote", "provider", "defaultBranch", "issues", "docs", "workspaces",
+	"clone.root", "clone.layout", "hyperlink", "qrcode",
+}
+
+// bindEnv binds the config keys to their GIT_OPEN_ environment variables. The
+// browser key is read from GIT_OPEN_BROWSER rather than BROWSER, which is a
+// list of launchers of its own, tried by configuredLaunchers.
+func bindEnv() {
+	for _, key := range append([]string{"browser"}, envKeys...) {
+		viper.BindEnv(key, "GIT_OPEN_"+strings.ReplaceAll(strings.ToUpper(key), ".", "_"))
+	}
 }
 
 func shouldAppendBranch(branchName string) bool {
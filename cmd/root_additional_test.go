@@ -11,13 +11,14 @@ import (
 )
 
 func Test_rootCmd_InvalidRemoteURLFormat(t *testing.T) {
+	withoutFastPath(t)
 	originalGetRemoteURLFunc := getRemoteURLFunc
 	defer func() { getRemoteURLFunc = originalGetRemoteURLFunc }()
 
 	_, cleanup := testhelper.SetupTestRepo(t, "https://github.com/test/repo.git", "main")
 	defer cleanup()
 
-	getRemoteURLFunc = func(repo *git.Repository) (string, error) {
+	getRemoteURLFunc = func(repo *git.Repository, fast *fastRepository, name string) (string, error) {
 		return "invalid-remote", nil
 	}
 
@@ -8,6 +8,7 @@ import (
 
 	"github.com/go-git/go-git/v5"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 	"github.com/zhaochunqi/git-open/internal/testhelper"
 	"path/filepath"
 )
@@ -249,6 +250,8 @@ func Test_initConfig(t *testing.T) {
 	}
 }
 func Test_rootCmd_ErrorHandling(t *testing.T) {
+	withoutFastPath(t)
+
 	// Save original functions
 	originalGetCurrentGitDirectoryFunc := getCurrentGitDirectoryFunc
 	originalGetRemoteURLFunc := getRemoteURLFunc
@@ -282,7 +285,7 @@ func Test_rootCmd_ErrorHandling(t *testing.T) {
 				_, cleanup := testhelper.SetupTestRepo(t, "https://github.com/test/repo.git", "main")
 				t.Cleanup(cleanup)
 
-				getRemoteURLFunc = func(repo *git.Repository) (string, error) {
+				getRemoteURLFunc = func(repo *git.Repository, fast *fastRepository, name string) (string, error) {
 					return "", errors.New("remote URL error")
 				}
 			},
@@ -459,3 +462,91 @@ func Test_initConfig_ErrorCases(t *testing.T) {
 		})
 	}
 }
+
+func Test_initConfig_BrowserOverrides(t *testing.T) {
+	originalBrowserCommand := BrowserCommand
+	originalBrowserOverrides := BrowserOverrides
+	originalCfgFile := cfgFile
+	t.Cleanup(func() {
+		BrowserCommand = originalBrowserCommand
+		BrowserOverrides = originalBrowserOverrides
+		cfgFile = originalCfgFile
+		viper.Reset()
+	})
+	t.Setenv("BROWSER", "")
+
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	config := `browser: firefox -P personal
+browsers:
+  github.example.com: firefox -P work {url}
+`
+	if err := os.WriteFile(configFile, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfgFile = configFile
+
+	initConfig()
+
+	if BrowserCommand != "firefox -P personal" {
+		t.Errorf("BrowserCommand = %q, want %q", BrowserCommand, "firefox -P personal")
+	}
+	if got := BrowserOverrides["github.example.com"]; got != "firefox -P work {url}" {
+		t.Errorf("BrowserOverrides[github.example.com] = %q, want %q", got, "firefox -P work {url}")
+	}
+}
+
+func Test_initConfig_IgnoresBrowserEnv(t *testing.T) {
+	originalBrowserCommand := BrowserCommand
+	originalCfgFile := cfgFile
+	t.Cleanup(func() {
+		BrowserCommand = originalBrowserCommand
+		cfgFile = originalCfgFile
+	})
+	viper.Reset()
+
+	// BROWSER is handled with its own list semantics when opening a URL, so it
+	// must not leak into the "browser" config value.
+	t.Setenv("BROWSER", "firefox:chromium")
+	t.Setenv("HOME", t.TempDir())
+	cfgFile = ""
+
+	initConfig()
+
+	if BrowserCommand != "" {
+		t.Errorf("BrowserCommand = %q, want empty", BrowserCommand)
+	}
+
+	// The prefixed variable still overrides the config key.
+	t.Setenv("GIT_OPEN_BROWSER", "lynx")
+	initConfig()
+
+	if BrowserCommand != "lynx" {
+		t.Errorf("BrowserCommand = %q, want %q", BrowserCommand, "lynx")
+	}
+}
+
+func Test_initConfig_Env(t *testing.T) {
+	originalCfgFile := cfgFile
+	originalRemoteName, originalProvider, originalDefaultBranch, originalCloneRoot := RemoteName, Provider, DefaultBranch, CloneRoot
+	t.Cleanup(func() {
+		cfgFile = originalCfgFile
+		RemoteName, Provider, DefaultBranch, CloneRoot = originalRemoteName, originalProvider, originalDefaultBranch, originalCloneRoot
+	})
+	viper.Reset()
+	t.Setenv("HOME", t.TempDir())
+	cfgFile = ""
+
+	t.Setenv("GIT_OPEN_REMOTE", "upstream")
+	t.Setenv("GIT_OPEN_DEFAULTBRANCH", "develop")
+	t.Setenv("GIT_OPEN_CLONE_ROOT", "/src")
+	// Generic names set for other tools are not read
+	t.Setenv("PROVIDER", "gitlab")
+	initConfig()
+
+	if RemoteName != "upstream" || DefaultBranch != "develop" || CloneRoot != "/src" {
+		t.Errorf("RemoteName, DefaultBranch, CloneRoot = %q, %q, %q, want %q, %q, %q", RemoteName, DefaultBranch, CloneRoot, "upstream", "develop", "/src")
+	}
+	if Provider != "" {
+		t.Errorf("Provider = %q, want it unset by PROVIDER", Provider)
+	}
+}
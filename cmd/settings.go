@@ -0,0 +1,214 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/zhaochunqi/git-open/pkg/gitopen"
+)
+
+// defaultRemoteName is the remote opened when none is configured.
+const defaultRemoteName = "origin"
+
+// RemoteName is the remote to open, from the "remote" config key.
+var RemoteName string
+
+// Provider is the hosting service from the "provider" config key, overriding
+// detection from the remote URL.
+var Provider string
+
+// DefaultBranch is the branch from the "defaultBranch" config key that opens
+// the repository home page instead of a branch page.
+var DefaultBranch string
+
+// remoteFlag is the remote given with --remote, which beats every config.
+var remoteFlag string
+
+// repoSettings are git-open's settings for one repository: the YAML config
+// overridden by the git-open.* keys of the repository's git config.
+type repoSettings struct {
+	// Remote is the remote to open.
+	Remote string
+	// Provider is the hosting service, detected from the remote URL when empty.
+	Provider string
+	// DefaultBranch is the branch opening the home page; main and master
+	// when empty.
+	DefaultBranch string
+	// WebURL is git-open.webURL, replacing the web URL derived from the remote.
+	WebURL string
+	// Browser is git-open.browser, taking precedence over the browser
+	// settings of the YAML config.
+	Browser string
+}
+
+// yamlSettings returns the settings from the YAML configs alone for the
+// repository whose common git directory is commonDir: the user config over the
+// project config, which only applies to the repository it was loaded from.
+func yamlSettings(commonDir string) repoSettings {
+	var settings repoSettings
+	if commonDir != "" && commonDir == projectCommonDir {
+		settings = projectSettings
+	}
+	overrides := []struct {
+		value string
+		field *string
+	}{
+		{RemoteName, &settings.Remote},
+		{Provider, &settings.Provider},
+		{DefaultBranch, &settings.DefaultBranch},
+	}
+	for _, o := range overrides {
+		if value := strings.TrimSpace(o.value); value != "" {
+			*o.field = value
+		}
+	}
+	if remoteFlag != "" {
+		settings.Remote = remoteFlag
+	} else if settings.Remote == "" {
+		settings.Remote = defaultRemoteName
+	}
+	return settings
+}
+
+// settingsFromGitConfig returns the YAML settings of the repository whose
+// common git directory is commonDir, overridden by the git-open.* keys of cfg.
+func settingsFromGitConfig(commonDir string, cfg *gitopen.Config) (repoSettings, error) {
+	settings := yamlSettings(commonDir)
+	configured := gitopen.Options{}.WithConfig(cfg)
+	for _, o := range []struct {
+		value string
+		field *string
+	}{
+		{configured.Remote, &settings.Remote},
+		{configured.Provider, &settings.Provider},
+		{configured.DefaultBranch, &settings.DefaultBranch},
+		{configured.WebURL, &settings.WebURL},
+	} {
+		if o.value != "" {
+			*o.field = o.value
+		}
+	}
+	// The browser is git-open's own setting, which the library does not read
+	if value, ok := cfg.Get(gitopen.ConfigSection, "", "browser"); ok && strings.TrimSpace(value) != "" {
+		settings.Browser = strings.TrimSpace(value)
+	}
+	if remoteFlag != "" {
+		settings.Remote = remoteFlag
+	}
+
+	if settings.Provider != "" {
+		if _, ok := providers.Lookup(settings.Provider); !ok {
+			return repoSettings{}, fmt.Errorf("unknown provider %q (want github, gitlab or bitbucket)", settings.Provider)
+		}
+	}
+	settings.WebURL = strings.TrimSuffix(settings.WebURL, "/")
+	return settings, nil
+}
+
+// settings returns the settings of r.
+func (r *fastRepository) settings() (repoSettings, error) {
+	return settingsFromGitConfig(r.commonDir, r.config)
+}
+
+// settingsFor returns the settings of repo.
+func settingsFor(repo *git.Repository) (repoSettings, error) {
+	_, settings, err := loadSettings(repo)
+	return settings, err
+}
+
+// loadSettings reads the config of repo and returns it with the settings of
+// repo. The config is nil when repo is not stored on disk or its config cannot
+// be read, in which case only the YAML settings apply.
+func loadSettings(repo *git.Repository) (*fastRepository, repoSettings, error) {
+	gitDir := gitopen.GitDir(repo)
+	if gitDir == "" {
+		return nil, yamlSettings(""), nil
+	}
+	fast, err := newFastRepository(gitDir, false)
+	if err != nil {
+		commonDir, _ := gitopen.CommonGitDir(gitDir)
+		return nil, yamlSettings(commonDir), nil
+	}
+	settings, err := fast.settings()
+	if err != nil {
+		return nil, repoSettings{}, err
+	}
+	return fast, settings, nil
+}
+
+// options returns the gitopen options resolving with these settings the
+// repository whose config fast has read, or nil when it could not be read,
+// and whose remote URL is remoteURL.
+func (s repoSettings) options(fast *fastRepository, remoteURL string) gitopen.Options {
+	opts := gitopen.Options{
+		Remote:        s.Remote,
+		Provider:      s.Provider,
+		DefaultBranch: s.DefaultBranch,
+		WebURL:        s.WebURL,
+		RemoteURL:     remoteURL,
+		SSHConfig:     userSSHConfig(),
+		Registry:      providers,
+		Logger:        logger,
+	}
+	if fast != nil {
+		opts.Config = fast.config
+	}
+	return opts
+}
+
+// settingSource describes where the setting key in effect comes from, checking
+// the layers in the opposite order settingsFromGitConfig applies them: the
+// git-open.* keys of cfg, then userValue and projectValue from the YAML
+// configs.
+func settingSource(cfg *gitopen.Config, key, userValue, projectValue string) string {
+	if value, ok := cfg.Get(gitopen.ConfigSection, "", key); ok && strings.TrimSpace(value) != "" {
+		return "git config " + gitopen.ConfigSection + "." + key
+	}
+	if strings.TrimSpace(userValue) != "" {
+		return "user config"
+	}
+	if projectValue != "" {
+		return "project config"
+	}
+	return "default"
+}
+
+// remoteSource describes where the remote of the repository whose common git
+// directory is commonDir and whose config is cfg comes from.
+func remoteSource(commonDir string, cfg *gitopen.Config) string {
+	if remoteFlag != "" {
+		return "--remote flag"
+	}
+	var project string
+	if commonDir != "" && commonDir == projectCommonDir {
+		project = projectSettings.Remote
+	}
+	return settingSource(cfg, "remote", RemoteName, project)
+}
+
+// hostingService returns the configured provider, or the one detected from
+// remoteURL.
+func (s repoSettings) hostingService(remoteURL string) *gitopen.Provider {
+	if provider, ok := providers.Lookup(s.Provider); ok {
+		logger.Debug("using configured provider", "provider", provider.Name)
+		return provider
+	}
+	provider := getHostingService(remoteURL)
+	logger.Debug("detected provider", "provider", provider.Name, "remote_url", remoteURL)
+	return provider
+}
+
+// shouldAppendBranch reports whether branchName opens a branch page rather
+// than the home page.
+func (s repoSettings) shouldAppendBranch(branchName string) bool {
+	if s.DefaultBranch != "" {
+		return branchName != s.DefaultBranch
+	}
+	return shouldAppendBranch(branchName)
+}
+
+// buildBranchURL is buildBranchURL using the configured provider.
+func (s repoSettings) buildBranchURL(baseURL, branchName, remoteURL string) string {
+	return s.hostingService(remoteURL).BranchURL(baseURL, branchName)
+}
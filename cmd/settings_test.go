@@ -0,0 +1,201 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/zhaochunqi/git-open/internal/testhelper"
+	"github.com/zhaochunqi/git-open/pkg/gitopen"
+)
+
+// appendGitConfig appends content to the config of the repository in dir.
+func appendGitConfig(t *testing.T, dir, content string) {
+	t.Helper()
+	f, err := os.OpenFile(filepath.Join(dir, ".git", "config"), os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(content); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// withYAMLSettings sets the settings read from the YAML config for a test.
+func withYAMLSettings(t *testing.T, remote, provider, defaultBranch string) {
+	t.Helper()
+	origRemote, origProvider, origDefault := RemoteName, Provider, DefaultBranch
+	RemoteName, Provider, DefaultBranch = remote, provider, defaultBranch
+	t.Cleanup(func() { RemoteName, Provider, DefaultBranch = origRemote, origProvider, origDefault })
+}
+
+func Test_settingsFromGitConfig(t *testing.T) {
+	withYAMLSettings(t, "upstream", "github", "")
+
+	tests := []struct {
+		name    string
+		src     string
+		want    repoSettings
+		wantErr bool
+	}{
+		{
+			name: "YAML settings only",
+			src:  "[core]\n\tbare = false\n",
+			want: repoSettings{Remote: "upstream", Provider: "github"},
+		},
+		{
+			name: "git config overrides YAML",
+			src:  "[git-open]\n\tremote = mirror\n\tprovider = gitlab\n\tdefaultBranch = develop\n\twebURL = https://code.example.com/team/repo/\n\tbrowser = firefox\n",
+			want: repoSettings{Remote: "mirror", Provider: "gitlab", DefaultBranch: "develop", WebURL: "https://code.example.com/team/repo", Browser: "firefox"},
+		},
+		{
+			name:    "unknown provider",
+			src:     "[git-open]\n\tprovider = gitea\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := gitopen.ParseConfig(tt.src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := settingsFromGitConfig("", cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("settingsFromGitConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && got != tt.want {
+				t.Errorf("settingsFromGitConfig() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_rootCmd_GitConfigSettings(t *testing.T) {
+	tests := []struct {
+		name      string
+		remoteURL string
+		branch    string
+		config    string
+		want      string
+	}{
+		{
+			name:      "remote",
+			remoteURL: "https://github.com/owner/fork.git",
+			branch:    "main",
+			config:    "[remote \"upstream\"]\n\turl = https://github.com/owner/repo.git\n[git-open]\n\tremote = upstream\n",
+			want:      "https://github.com/owner/repo",
+		},
+		{
+			name:      "web URL of an unsupported remote",
+			remoteURL: "/srv/mirrors/repo.git",
+			branch:    "main",
+			config:    "[git-open]\n\twebURL = https://github.com/owner/repo\n",
+			want:      "https://github.com/owner/repo",
+		},
+		{
+			name:      "default branch",
+			remoteURL: "https://github.com/owner/repo.git",
+			branch:    "develop",
+			config:    "[git-open]\n\tdefaultBranch = develop\n",
+			want:      "https://github.com/owner/repo",
+		},
+		{
+			name:      "main is a branch page with another default branch",
+			remoteURL: "https://github.com/owner/repo.git",
+			branch:    "main",
+			config:    "[git-open]\n\tdefaultBranch = develop\n",
+			want:      "https://github.com/owner/repo/tree/main",
+		},
+		{
+			name:      "provider of a self-hosted instance",
+			remoteURL: "https://code.example.com/group/repo.git",
+			branch:    "feature",
+			config:    "[git-open]\n\tprovider = gitlab\n",
+			want:      "https://code.example.com/group/repo/-/tree/feature",
+		},
+	}
+
+	for _, tt := range tests {
+		for _, fast := range []bool{true, false} {
+			name := tt.name + " (go-git)"
+			if fast {
+				name = tt.name + " (fast path)"
+			}
+			t.Run(name, func(t *testing.T) {
+				dir, cleanup := testhelper.SetupTestRepo(t, tt.remoteURL, tt.branch)
+				defer cleanup()
+				appendGitConfig(t, dir, tt.config)
+				if !fast {
+					withoutFastPath(t)
+				}
+
+				buf := new(bytes.Buffer)
+				cmd := &cobra.Command{}
+				cmd.SetOut(buf)
+				cmd.Flags().Bool("plain", true, "")
+				if err := rootCmd.RunE(cmd, []string{}); err != nil {
+					t.Fatalf("rootCmd.RunE() error = %v", err)
+				}
+				if want := "Web URL: " + tt.want + "\n"; buf.String() != want {
+					t.Errorf("rootCmd output = %q, want %q", buf.String(), want)
+				}
+			})
+		}
+	}
+}
+
+func Test_rootCmd_GitConfigBrowser(t *testing.T) {
+	dir, cleanup := testhelper.SetupTestRepo(t, "https://github.com/owner/repo.git", "main")
+	defer cleanup()
+	appendGitConfig(t, dir, "[git-open]\n\tbrowser = firefox --new-tab\n")
+
+	originalOpen := OpenURLInBrowser
+	originalCommand := BrowserCommand
+	BrowserCommand = "chromium"
+	defer func() {
+		OpenURLInBrowser = originalOpen
+		BrowserCommand = originalCommand
+	}()
+
+	var gotCommand string
+	OpenURLInBrowser = func(url string) error {
+		gotCommand = BrowserCommand
+		return nil
+	}
+
+	cmd := &cobra.Command{}
+	cmd.Flags().Bool("plain", false, "")
+	if err := rootCmd.RunE(cmd, []string{}); err != nil {
+		t.Fatalf("rootCmd.RunE() error = %v", err)
+	}
+	if gotCommand != "firefox --new-tab" {
+		t.Errorf("browser command = %q, want git-open.browser", gotCommand)
+	}
+	if BrowserCommand != "chromium" {
+		t.Errorf("BrowserCommand = %q after opening, want the YAML browser restored", BrowserCommand)
+	}
+}
+
+func Test_settingsFromGitConfig_RemoteFlag(t *testing.T) {
+	withYAMLSettings(t, "upstream", "", "")
+	original := remoteFlag
+	remoteFlag = "fork"
+	t.Cleanup(func() { remoteFlag = original })
+
+	cfg, err := gitopen.ParseConfig("[git-open]\n\tremote = mirror\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := settingsFromGitConfig("", cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Remote != "fork" {
+		t.Errorf("settingsFromGitConfig().Remote = %q, want the --remote flag %q", got.Remote, "fork")
+	}
+}
@@ -0,0 +1,348 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/spf13/cobra"
+	"github.com/zhaochunqi/git-open/pkg/gitopen"
+)
+
+// errNotSubmodule is returned when --superproject is used outside a submodule.
+var errNotSubmodule = errors.New("not inside a submodule")
+
+// submoduleResult is one submodule listed by the submodules command.
+type submoduleResult struct {
+	Path      string `json:"path"`
+	Commit    string `json:"commit,omitempty"`
+	RemoteURL string `json:"remoteURL,omitempty"`
+	WebURL    string `json:"webURL,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// submodulesCmd represents the submodules command
+var submodulesCmd = &cobra.Command{
+	Use:   "submodules",
+	Short: "List the submodules of the repository with their web URLs",
+	Long: `List each submodule of the Git repository in the current working directory
+with the web URL of the commit recorded for it, as a table or as JSON. Relative
+submodule URLs are resolved against the repository's origin remote.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		asJSON, _ := cmd.Flags().GetBool("json")
+
+		repo, err := getCurrentGitDirectory()
+		if err != nil {
+			return fmt.Errorf("error getting git directory: %w", err)
+		}
+		results, err := listSubmodules(repo)
+		if err != nil {
+			return err
+		}
+
+		if asJSON {
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			return enc.Encode(results)
+		}
+		return writeSubmoduleTable(cmd.OutOrStdout(), results)
+	},
+}
+
+// resolveSuperprojectWebURL is resolveWebURL for the superproject of the
+// submodule in the current working directory.
+func resolveSuperprojectWebURL() (*resolvedRepository, error) {
+	repo, _, err := findSuperproject(".")
+	if err != nil {
+		return nil, fmt.Errorf("error getting superproject: %w", err)
+	}
+	return resolveRepositoryWebURL(repo)
+}
+
+// findSuperproject returns the superproject of the submodule containing path
+// and the submodule's path within it. Like git, the superproject is found from
+// the submodule's git directory, <superproject git dir>/modules/<name>, so the
+// submodule may be checked out anywhere its core.worktree says. Submodules
+// with a .git directory of their own, as cloned by old versions of git, are
+// looked up in the .gitmodules of the repository containing them instead.
+func findSuperproject(path string) (*git.Repository, string, error) {
+	gitDir, workTree, err := gitopen.FindGitDir(path, discovery(), logger)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if superGitDir, name, ok := superprojectGitDir(gitDir); ok {
+		super, err := openGitDir(superGitDir)
+		if err != nil {
+			return nil, "", err
+		}
+		modules, err := readGitmodules(super)
+		if err != nil {
+			return nil, "", err
+		}
+		if module, ok := modules.Submodules[name]; ok {
+			return super, strings.Trim(module.Path, "/"), nil
+		}
+	}
+
+	if workTree == "" {
+		return nil, "", errNotSubmodule
+	}
+	return findEnclosingSuperproject(workTree)
+}
+
+// superprojectGitDir returns the git directory of the superproject and the
+// submodule's name when gitDir is the git directory of a submodule, kept below
+// the superproject's as modules/<name>. The names of nested submodules are
+// the nearest ones.
+func superprojectGitDir(gitDir string) (string, string, bool) {
+	for dir := gitDir; ; {
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", "", false
+		}
+		if filepath.Base(parent) == "modules" {
+			super := filepath.Dir(parent)
+			if gitopen.IsGitDir(super) {
+				name, err := filepath.Rel(parent, gitDir)
+				if err != nil {
+					return "", "", false
+				}
+				return super, filepath.ToSlash(name), true
+			}
+		}
+		dir = parent
+	}
+}
+
+// openGitDir opens the repository with the git directory gitDir like
+// gitopen.OpenGitDir. Superprojects without a worktree have no submodules
+// checked out.
+func openGitDir(gitDir string) (*git.Repository, error) {
+	repo, err := gitopen.OpenGitDir(gitDir)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := repo.Worktree(); errors.Is(err, git.ErrIsBareRepository) {
+		return nil, errNotSubmodule
+	}
+	return repo, nil
+}
+
+// findEnclosingSuperproject returns the repository containing the submodule
+// worktree root that lists it in .gitmodules, and the submodule's path in it.
+func findEnclosingSuperproject(root string) (*git.Repository, string, error) {
+	parent := filepath.Dir(root)
+	if parent == root {
+		return nil, "", errNotSubmodule
+	}
+	super, err := openRepository(parent)
+	if errors.Is(err, git.ErrRepositoryNotExists) {
+		return nil, "", errNotSubmodule
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	superRoot, err := worktreeRoot(super)
+	if err != nil {
+		return nil, "", err
+	}
+	rel, err := filepath.Rel(superRoot, root)
+	if err != nil {
+		return nil, "", err
+	}
+	rel = filepath.ToSlash(rel)
+
+	modules, err := readGitmodules(super)
+	if err != nil {
+		return nil, "", err
+	}
+	for _, module := range modules.Submodules {
+		if strings.Trim(module.Path, "/") == rel {
+			return super, rel, nil
+		}
+	}
+	return nil, "", errNotSubmodule
+}
+
+// readGitmodules parses the .gitmodules file in the worktree of repo. A
+// repository without one has no submodules.
+func readGitmodules(repo *git.Repository) (*config.Modules, error) {
+	modules := config.NewModules()
+	wt, err := repo.Worktree()
+	if err != nil {
+		return nil, err
+	}
+	b, err := os.ReadFile(filepath.Join(wt.Filesystem.Root(), ".gitmodules"))
+	if os.IsNotExist(err) {
+		return modules, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := modules.Unmarshal(b); err != nil {
+		return nil, fmt.Errorf("error parsing .gitmodules: %w", err)
+	}
+	return modules, nil
+}
+
+// listSubmodules returns the submodules of repo, sorted by path, with the web
+// URL of the commit HEAD records for each of them. Like git, the URL of a
+// submodule is the one in the superproject's config, as set by "git submodule
+// init" or "sync" or by the user, and the one in .gitmodules otherwise.
+func listSubmodules(repo *git.Repository) ([]submoduleResult, error) {
+	modules, err := readGitmodules(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	// Relative submodule URLs need the superproject's remote, but absolute
+	// ones do not, so a missing remote is only reported per submodule
+	fast, settings, superRemoteErr := loadSettings(repo)
+	var superRemoteURL string
+	if superRemoteErr == nil {
+		superRemoteURL, superRemoteErr = getRemoteURLFunc(repo, fast, settings.Remote)
+	}
+
+	var results []submoduleResult
+	for _, module := range modules.Submodules {
+		result := submoduleResult{Path: module.Path}
+		var errs []string
+		if commit, err := recordedSubmoduleCommit(repo, module.Path); err != nil {
+			errs = append(errs, err.Error())
+		} else {
+			result.Commit = commit
+		}
+
+		remoteURL := module.URL
+		if fast != nil {
+			if configured, ok := fast.config.Get("submodule", module.Name, "url"); ok && configured != "" {
+				remoteURL = configured
+			}
+		}
+		if isRelativeSubmoduleURL(remoteURL) {
+			if superRemoteErr != nil {
+				errs = append(errs, fmt.Sprintf("error getting remote URL: %v", superRemoteErr))
+				result.Error = strings.Join(errs, "; ")
+				results = append(results, result)
+				continue
+			}
+			remoteURL = resolveSubmoduleURL(superRemoteURL, remoteURL)
+		}
+		if fast != nil {
+			remoteURL = fast.config.RewriteURL(remoteURL)
+		}
+		result.RemoteURL = remoteURL
+
+		webURL := convertToWebURL(remoteURL)
+		if webURL == "" {
+			errs = append(errs, fmt.Sprintf("unsupported remote URL format: %s", remoteURL))
+		} else if result.Commit != "" {
+			result.WebURL = buildBranchURL(webURL, result.Commit, remoteURL)
+		} else {
+			result.WebURL = webURL
+		}
+		result.Error = strings.Join(errs, "; ")
+		results = append(results, result)
+	}
+
+	sort.Slice(results, func(i, j int) bool { return results[i].Path < results[j].Path })
+	return results, nil
+}
+
+// recordedSubmoduleCommit returns the commit HEAD of repo records for the
+// submodule at path.
+func recordedSubmoduleCommit(repo *git.Repository, path string) (string, error) {
+	head, err := repo.Head()
+	if err != nil {
+		return "", fmt.Errorf("error getting HEAD: %w", err)
+	}
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return "", err
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return "", err
+	}
+	entry, err := tree.FindEntry(path)
+	if err != nil {
+		return "", fmt.Errorf("submodule not committed: %w", err)
+	}
+	if entry.Mode != filemode.Submodule {
+		return "", fmt.Errorf("%s is not a submodule in HEAD", path)
+	}
+	return entry.Hash.String(), nil
+}
+
+// isRelativeSubmoduleURL reports whether a .gitmodules URL is relative to the
+// superproject's remote.
+func isRelativeSubmoduleURL(rawURL string) bool {
+	return strings.HasPrefix(rawURL, "./") || strings.HasPrefix(rawURL, "../")
+}
+
+// resolveSubmoduleURL resolves a relative submodule URL against the remote URL
+// of the superproject the way git does: each "../" removes one path component
+// of the superproject URL, e.g. "../lib.git" relative to
+// "git@github.com:user/app.git" is "git@github.com:user/lib.git".
+func resolveSubmoduleURL(superURL, submoduleURL string) string {
+	if !isRelativeSubmoduleURL(submoduleURL) {
+		return submoduleURL
+	}
+
+	base := strings.TrimSuffix(superURL, "/")
+	rel := submoduleURL
+	separator := "/"
+	for {
+		switch {
+		case strings.HasPrefix(rel, "./"):
+			rel = rel[len("./"):]
+		case strings.HasPrefix(rel, "../"):
+			rel = rel[len("../"):]
+			i := strings.LastIndexAny(base, "/:")
+			if i < 0 || strings.HasSuffix(base[:i], "/") {
+				// Stepped past the host
+				return ""
+			}
+			if base[i] == ':' {
+				separator = ":"
+			}
+			base = base[:i]
+		default:
+			return base + separator + rel
+		}
+	}
+}
+
+func writeSubmoduleTable(w io.Writer, results []submoduleResult) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "PATH\tCOMMIT\tWEB URL")
+	for _, result := range results {
+		commit := "-"
+		if len(result.Commit) >= 7 {
+			commit = result.Commit[:7]
+		}
+		if result.Error != "" {
+			fmt.Fprintf(tw, "%s\t%s\terror: %s\n", result.Path, commit, result.Error)
+			continue
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", result.Path, commit, result.WebURL)
+	}
+	return tw.Flush()
+}
+
+func init() {
+	submodulesCmd.Flags().Bool("json", false, "Print the submodules as JSON.")
+	rootCmd.AddCommand(submodulesCmd)
+}
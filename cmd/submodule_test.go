@@ -0,0 +1,272 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/zhaochunqi/git-open/internal/testhelper"
+)
+
+func Test_resolveSubmoduleURL(t *testing.T) {
+	tests := []struct {
+		name         string
+		superURL     string
+		submoduleURL string
+		want         string
+	}{
+		{"absolute URL", "https://github.com/user/app.git", "https://gitlab.com/group/lib.git", "https://gitlab.com/group/lib.git"},
+		{"sibling https", "https://github.com/user/app.git", "../lib.git", "https://github.com/user/lib.git"},
+		{"sibling scp", "git@github.com:user/app.git", "../lib.git", "git@github.com:user/lib.git"},
+		{"other owner scp", "git@github.com:user/app.git", "../../other/lib.git", "git@github.com:other/lib.git"},
+		{"scp without owner", "git@example.com:app.git", "../lib.git", "git@example.com:lib.git"},
+		{"child", "https://github.com/user/app", "./lib", "https://github.com/user/app/lib"},
+		{"trailing slash", "https://github.com/user/app/", "../lib", "https://github.com/user/lib"},
+		{"past the host", "https://github.com/app.git", "../../lib.git", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resolveSubmoduleURL(tt.superURL, tt.submoduleURL); got != tt.want {
+				t.Errorf("resolveSubmoduleURL(%q, %q) = %q, want %q", tt.superURL, tt.submoduleURL, got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_findSuperproject(t *testing.T) {
+	t.Run("submodule", func(t *testing.T) {
+		superDir, _, cleanup := testhelper.SetupTestSubmodule(t, "git@github.com:user/app.git", "../lib.git", "git@github.com:user/lib.git")
+		defer cleanup()
+
+		super, path, err := findSuperproject(".")
+		if err != nil {
+			t.Fatalf("findSuperproject() error = %v", err)
+		}
+		if path != "lib" {
+			t.Errorf("findSuperproject() path = %q, want lib", path)
+		}
+		root, err := worktreeRoot(super)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if root != superDir {
+			t.Errorf("findSuperproject() superproject = %s, want %s", root, superDir)
+		}
+	})
+
+	t.Run("checked out elsewhere", func(t *testing.T) {
+		superDir, subDir, cleanup := testhelper.SetupTestSubmodule(t, "git@github.com:user/app.git", "../lib.git", "git@github.com:user/lib.git")
+		defer cleanup()
+
+		// Move the worktree out of the superproject, pointing its .git file
+		// and the core.worktree of its git directory at the new place
+		gitDir := filepath.Join(superDir, ".git", "modules", "lib")
+		elsewhere := filepath.Join(filepath.Dir(superDir), "lib-elsewhere")
+		if err := os.Rename(subDir, elsewhere); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(elsewhere, ".git"), []byte("gitdir: "+gitDir+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		runGitConfig(t, gitDir, "core.worktree", elsewhere)
+		if err := os.Chdir(elsewhere); err != nil {
+			t.Fatal(err)
+		}
+
+		super, path, err := findSuperproject(".")
+		if err != nil {
+			t.Fatalf("findSuperproject() error = %v", err)
+		}
+		if root, _ := worktreeRoot(super); root != superDir || path != "lib" {
+			t.Errorf("findSuperproject() = %s, %q, want %s, lib", root, path, superDir)
+		}
+	})
+
+	t.Run("embedded git directory", func(t *testing.T) {
+		superDir, subDir, cleanup := testhelper.SetupTestSubmodule(t, "git@github.com:user/app.git", "../lib.git", "git@github.com:user/lib.git")
+		defer cleanup()
+
+		// Submodules cloned by git before 1.7.8 keep their git directory
+		if err := os.Remove(filepath.Join(subDir, ".git")); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Rename(filepath.Join(superDir, ".git", "modules", "lib"), filepath.Join(subDir, ".git")); err != nil {
+			t.Fatal(err)
+		}
+		runGitConfig(t, filepath.Join(subDir, ".git"), "--unset", "core.worktree")
+
+		super, path, err := findSuperproject(".")
+		if err != nil {
+			t.Fatalf("findSuperproject() error = %v", err)
+		}
+		if root, _ := worktreeRoot(super); root != superDir || path != "lib" {
+			t.Errorf("findSuperproject() = %s, %q, want %s, lib", root, path, superDir)
+		}
+	})
+
+	t.Run("not a submodule", func(t *testing.T) {
+		_, cleanup := testhelper.SetupTestRepo(t, "https://github.com/user/app.git", "main")
+		defer cleanup()
+
+		if _, _, err := findSuperproject("."); !errors.Is(err, errNotSubmodule) {
+			t.Errorf("findSuperproject() error = %v, want %v", err, errNotSubmodule)
+		}
+	})
+}
+
+// runGitConfig runs git config with args on the repository at gitDir.
+func runGitConfig(t *testing.T, gitDir string, args ...string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	cmd := exec.Command("git", append([]string{"config", "--file", filepath.Join(gitDir, "config")}, args...)...)
+	// Outside of the repositories, whose worktrees may have moved
+	cmd.Dir = t.TempDir()
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git config %q failed: %v\n%s", args, err, out)
+	}
+}
+
+func Test_rootCmd_Superproject(t *testing.T) {
+	_, _, cleanup := testhelper.SetupTestSubmodule(t, "git@github.com:user/app.git", "../lib.git", "git@github.com:user/lib.git")
+	defer cleanup()
+
+	for _, tt := range []struct {
+		superproject bool
+		want         string
+	}{
+		{false, "Web URL: https://github.com/user/lib\n"},
+		{true, "Web URL: https://github.com/user/app\n"},
+	} {
+		buf := new(bytes.Buffer)
+		cmd := &cobra.Command{}
+		cmd.SetOut(buf)
+		cmd.Flags().Bool("plain", true, "")
+		cmd.Flags().Bool("superproject", tt.superproject, "")
+
+		if err := rootCmd.RunE(cmd, []string{}); err != nil {
+			t.Fatalf("rootCmd.RunE() error = %v", err)
+		}
+		if got := buf.String(); got != tt.want {
+			t.Errorf("rootCmd.RunE() with superproject=%v output = %q, want %q", tt.superproject, got, tt.want)
+		}
+	}
+}
+
+func Test_submodulesCmd(t *testing.T) {
+	superDir, _, cleanup := testhelper.SetupTestSubmodule(t, "git@github.com:user/app.git", "../lib.git", "git@github.com:user/lib.git")
+	defer cleanup()
+	if err := os.Chdir(superDir); err != nil {
+		t.Fatal(err)
+	}
+
+	repo, err := getCurrentGitDirectory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	commit, err := recordedSubmoduleCommit(repo, "lib")
+	if err != nil {
+		t.Fatalf("recordedSubmoduleCommit() error = %v", err)
+	}
+
+	newCmd := func(asJSON bool) (*cobra.Command, *bytes.Buffer) {
+		buf := new(bytes.Buffer)
+		cmd := &cobra.Command{}
+		cmd.SetOut(buf)
+		cmd.Flags().Bool("json", asJSON, "")
+		return cmd, buf
+	}
+
+	t.Run("table", func(t *testing.T) {
+		cmd, buf := newCmd(false)
+		if err := submodulesCmd.RunE(cmd, []string{}); err != nil {
+			t.Fatalf("submodulesCmd.RunE() error = %v", err)
+		}
+		want := "PATH  COMMIT   WEB URL\n" +
+			"lib   " + commit[:7] + "  https://github.com/user/lib/tree/" + commit + "\n"
+		if got := buf.String(); got != want {
+			t.Errorf("submodules output = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		cmd, buf := newCmd(true)
+		if err := submodulesCmd.RunE(cmd, []string{}); err != nil {
+			t.Fatalf("submodulesCmd.RunE() error = %v", err)
+		}
+		var got []submoduleResult
+		if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+			t.Fatalf("submodules output is not JSON: %v\n%s", err, buf.String())
+		}
+		want := submoduleResult{
+			Path:      "lib",
+			Commit:    commit,
+			RemoteURL: "git@github.com:user/lib.git",
+			WebURL:    "https://github.com/user/lib/tree/" + commit,
+		}
+		if len(got) != 1 || got[0] != want {
+			t.Errorf("submodules JSON = %+v, want [%+v]", got, want)
+		}
+	})
+
+	t.Run("configured URL", func(t *testing.T) {
+		// The superproject's config overrides .gitmodules, and its
+		// insteadOf rules apply
+		gitDir := filepath.Join(superDir, ".git")
+		runGitConfig(t, gitDir, "submodule.lib.url", "gh:user/lib-fork.git")
+		runGitConfig(t, gitDir, "url.git@github.com:.insteadOf", "gh:")
+		t.Cleanup(func() {
+			runGitConfig(t, gitDir, "submodule.lib.url", "git@github.com:user/lib.git")
+			runGitConfig(t, gitDir, "--unset", "url.git@github.com:.insteadOf")
+		})
+
+		results, err := listSubmodules(repo)
+		if err != nil {
+			t.Fatalf("listSubmodules() error = %v", err)
+		}
+		if len(results) != 1 || results[0].RemoteURL != "git@github.com:user/lib-fork.git" {
+			t.Errorf("listSubmodules() = %+v, want the configured URL rewritten", results)
+		}
+	})
+
+	t.Run("errors are kept", func(t *testing.T) {
+		_, cleanup := testhelper.SetupTestRepo(t, "https://github.com/user/other.git", "main")
+		defer cleanup()
+		if err := os.WriteFile(".gitmodules", []byte("[submodule \"lib\"]\n\tpath = lib\n\turl = /srv/lib\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		other, err := getCurrentGitDirectory()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		results, err := listSubmodules(other)
+		if err != nil {
+			t.Fatalf("listSubmodules() error = %v", err)
+		}
+		if len(results) != 1 || !strings.Contains(results[0].Error, "submodule not committed") || !strings.Contains(results[0].Error, "unsupported remote URL format") {
+			t.Errorf("listSubmodules() = %+v, want both errors", results)
+		}
+	})
+
+	t.Run("no submodules", func(t *testing.T) {
+		_, cleanup := testhelper.SetupTestRepo(t, "https://github.com/user/other.git", "main")
+		defer cleanup()
+
+		cmd, buf := newCmd(false)
+		if err := submodulesCmd.RunE(cmd, []string{}); err != nil {
+			t.Fatalf("submodulesCmd.RunE() error = %v", err)
+		}
+		if got := strings.TrimSpace(buf.String()); got != "PATH  COMMIT  WEB URL" {
+			t.Errorf("submodules output = %q, want only the header", got)
+		}
+	})
+}
@@ -0,0 +1,7 @@
+package cmd
+
+import "github.com/zhaochunqi/git-open/pkg/gitopen"
+
+// Target describes the repository page git-open resolved, exposing the fields
+// available to --format templates. It is the Target of the gitopen library.
+type Target = gitopen.Target
@@ -0,0 +1,18 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package cmd
+
+import (
+	"errors"
+	"os"
+)
+
+// isTerminal reports whether f is a terminal, which is never known here.
+func isTerminal(f *os.File) bool {
+	return false
+}
+
+// makeRaw is not supported here, leaving the picker to the numbered prompt.
+func makeRaw(f *os.File) (func(), error) {
+	return nil, errors.New("raw terminal mode is not supported on this platform")
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/zhaochunqi/git-open/pkg/gitopen"
+)
+
+// verbose is set by --verbose to trace how the URL is resolved.
+var verbose bool
+
+// logger traces the steps of resolving the URL: the directories searched, the
+// config files read, the remote selected, the URL rewrites and the provider.
+// It discards everything unless --verbose or GIT_OPEN_TRACE is set. Attribute
+// keys are snake_case, e.g. remote_url, so that traces can be grepped.
+var logger = slog.New(slog.DiscardHandler)
+
+// traceOutput is where traces are written. It can be replaced for testing.
+var traceOutput io.Writer = os.Stderr
+
+// initTracing enables tracing to traceOutput with --verbose or a true
+// GIT_OPEN_TRACE.
+func initTracing() {
+	if !verbose && !gitopen.ParseBool(os.Getenv("GIT_OPEN_TRACE")) {
+		logger = slog.New(slog.DiscardHandler)
+		return
+	}
+	logger = slog.New(slog.NewTextHandler(traceOutput, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		// Timestamps only get in the way of reading a single run
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) == 0 && a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+}
@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/zhaochunqi/git-open/internal/testhelper"
+)
+
+func Test_tracing(t *testing.T) {
+	dir, cleanup := testhelper.SetupTestRepo(t, "gh:owner/repo", "main")
+	defer cleanup()
+	appendGitConfig(t, dir, "[url \"git@github.com:\"]\n\tinsteadOf = gh:\n")
+	withYAMLSettings(t, "", "", "")
+
+	originalOutput, originalLogger, originalVerbose := traceOutput, logger, verbose
+	t.Cleanup(func() { traceOutput, logger, verbose = originalOutput, originalLogger, originalVerbose })
+
+	tests := []struct {
+		name    string
+		verbose bool
+		env     string
+		want    []string
+	}{
+		{"off", false, "", nil},
+		{"GIT_OPEN_TRACE=0", false, "0", nil},
+		{"--verbose", true, "", []string{
+			`msg="searching for repository" dir=` + dir,
+			`msg="read git config" path=` + dir,
+			`msg="rewrote URL" url=gh:owner/repo instead_of=gh: base=git@github.com:`,
+			`msg="selected remote" remote=origin source=default url=git@github.com:owner/repo`,
+			`msg="resolved URL" url=https://github.com/owner/repo`,
+		}},
+		{"GIT_OPEN_TRACE=1", false, "1", []string{`msg="resolved URL" url=https://github.com/owner/repo`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			traceOutput, verbose = buf, tt.verbose
+			t.Setenv("GIT_OPEN_TRACE", tt.env)
+			initTracing()
+
+			cmd := &cobra.Command{}
+			cmd.SetOut(new(bytes.Buffer))
+			cmd.Flags().Bool("plain", true, "")
+			if err := rootCmd.RunE(cmd, nil); err != nil {
+				t.Fatalf("rootCmd.RunE() error = %v", err)
+			}
+
+			got := buf.String()
+			if tt.want == nil && got != "" {
+				t.Errorf("traced %q with tracing off", got)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("trace lacks %q:\n%s", want, got)
+				}
+			}
+		})
+	}
+}
+
+func Test_tracing_ReadsConfigOnce(t *testing.T) {
+	withoutFastPath(t)
+	dir, cleanup := testhelper.SetupTestRepo(t, "git@github.com:owner/repo.git", "feature")
+	defer cleanup()
+	withYAMLSettings(t, "", "", "")
+
+	originalOutput, originalLogger, originalVerbose := traceOutput, logger, verbose
+	t.Cleanup(func() { traceOutput, logger, verbose = originalOutput, originalLogger, originalVerbose })
+	buf := new(bytes.Buffer)
+	traceOutput, verbose = buf, true
+	initTracing()
+
+	cmd := &cobra.Command{}
+	cmd.SetOut(new(bytes.Buffer))
+	cmd.SetErr(new(bytes.Buffer))
+	cmd.Flags().Bool("plain", true, "")
+	if err := rootCmd.RunE(cmd, nil); err != nil {
+		t.Fatalf("rootCmd.RunE() error = %v", err)
+	}
+
+	read := `msg="read git config" path=` + filepath.Join(dir, ".git", "config")
+	if n := strings.Count(buf.String(), read); n != 1 {
+		t.Errorf("read the repository config %d times, want once:\n%s", n, buf.String())
+	}
+}
+
+func Test_tracing_UnpushedBranchSearchesOnce(t *testing.T) {
+	dir, cleanup := testhelper.SetupTestRepo(t, "git@github.com:owner/repo.git", "feature")
+	defer cleanup()
+	withYAMLSettings(t, "", "", "")
+
+	originalOutput, originalLogger, originalVerbose := traceOutput, logger, verbose
+	t.Cleanup(func() {
+		traceOutput, logger, verbose = originalOutput, originalLogger, originalVerbose
+		startRepository.dir, startRepository.gitDir, startRepository.workTree = "", "", ""
+	})
+	buf := new(bytes.Buffer)
+	traceOutput, verbose = buf, true
+	initTracing()
+
+	// Like initConfig, then the fast path falls back to go-git for the
+	// unpushed branch
+	if err := loadProjectConfig("."); err != nil {
+		t.Fatal(err)
+	}
+	cmd := &cobra.Command{}
+	cmd.SetOut(new(bytes.Buffer))
+	cmd.SetErr(new(bytes.Buffer))
+	cmd.Flags().Bool("plain", true, "")
+	if err := rootCmd.RunE(cmd, nil); err != nil {
+		t.Fatalf("rootCmd.RunE() error = %v", err)
+	}
+
+	got := buf.String()
+	if !strings.Contains(got, "using go-git") {
+		t.Fatalf("trace lacks the go-git fallback:\n%s", got)
+	}
+	for _, msg := range []string{`msg="searching for repository"`, `msg="read git config" path=` + filepath.Join(dir, ".git", "config")} {
+		if n := strings.Count(got, msg); n != 1 {
+			t.Errorf("traced %s %d times, want once:\n%s", msg, n, got)
+		}
+	}
+}
@@ -0,0 +1,276 @@
+package cmd
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// webLocationKind is the kind of page a forge web URL points to.
+type webLocationKind int
+
+const (
+	// repoLocation is the repository home page.
+	repoLocation webLocationKind = iota
+	// pathLocation is a file or directory at a ref (blob, tree or src pages).
+	pathLocation
+	// commitLocation is a single commit.
+	commitLocation
+)
+
+// webURLStyle identifies forges whose web and clone URLs differ in shape
+// from the common host/owner/repo layout.
+type webURLStyle int
+
+const (
+	// standardStyle is the host/owner/repo layout of GitHub, GitLab, Bitbucket
+	// Cloud and most self-hosted forges.
+	standardStyle webURLStyle = iota
+	// azureStyle is Azure DevOps: org/project/_git/repo.
+	azureStyle
+	// bitbucketServerStyle is Bitbucket Server/Data Center:
+	// projects/KEY/repos/slug.
+	bitbucketServerStyle
+)
+
+// webLocation is a page of a repository on a forge, parsed from a web URL.
+type webLocation struct {
+	// Host is the web host, e.g. "github.com".
+	Host string
+	// RepoPath is the repository path below the host, e.g. "zhaochunqi/git-open"
+	// or "group/subgroup/project".
+	RepoPath string
+	// Owner is the user, organisation or group path owning the repository, e.g.
+	// "group/subgroup", "org/project" on Azure DevOps or the project key on
+	// Bitbucket Server.
+	Owner string
+	// Repo is the repository name.
+	Repo string
+	// Style is the URL layout of the forge.
+	Style webURLStyle
+	// Kind is the kind of page.
+	Kind webLocationKind
+	// Rest holds the path segments after the page kind: the ref followed by the
+	// file path for pathLocation (refs may contain slashes, so the split is
+	// ambiguous), and the commit SHA for commitLocation.
+	Rest []string
+	// Line is the line number selected by the URL fragment, or 0.
+	Line int
+}
+
+// Name returns the repository name in the form used by the repo command,
+// e.g. "github.com/zhaochunqi/git-open".
+func (l *webLocation) Name() string {
+	return l.Host + "/" + l.RepoPath
+}
+
+// webPageKinds maps the path segment that introduces a page to its kind.
+var webPageKinds = map[string]webLocationKind{
+	"blob":    pathLocation,
+	"tree":    pathLocation,
+	"blame":   pathLocation,
+	"raw":     pathLocation,
+	"src":     pathLocation,
+	"commit":  commitLocation,
+	"commits": commitLocation,
+}
+
+// lineFragmentPattern matches line anchors: "L10" and "L10-L20" (GitHub,
+// GitLab) and "lines-10" and "lines-10:20" (Bitbucket).
+var lineFragmentPattern = regexp.MustCompile(`^(?:L|lines-)(\d+)`)
+
+// parseWebURL parses a repository web URL such as one copied from the
+// browser, the inverse of convertToWebURL. GitLab's "/-/" separator allows
+// nested group paths, which may be named like pages; otherwise the repository
+// path ends before the first page kind segment such as "blob" or "commit".
+// URLs with "." or ".." segments are rejected, as their paths are joined to
+// local directories.
+func parseWebURL(rawURL string) (*webLocation, error) {
+	parsed, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return nil, fmt.Errorf("invalid web URL %q: %w", rawURL, err)
+	}
+	if parsed.Host == "" || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return nil, fmt.Errorf("invalid web URL %q: want an http(s) URL", rawURL)
+	}
+
+	var segments []string
+	for _, segment := range strings.Split(parsed.Path, "/") {
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	if hasDotSegment(segments) {
+		return nil, fmt.Errorf("invalid web URL %q: \".\" and \"..\" path segments are not allowed", rawURL)
+	}
+
+	location := &webLocation{Host: parsed.Host}
+	if i := indexOfSegment(segments, "_git"); i >= 1 && i+1 < len(segments) {
+		return parseAzureWebURL(location, segments, i, parsed)
+	}
+	if len(segments) >= 4 && (segments[0] == "projects" || segments[0] == "users") && segments[2] == "repos" {
+		return parseBitbucketServerWebURL(location, segments, parsed)
+	}
+
+	repoEnd, pageStart := len(segments), len(segments)
+	if i := indexOfSegment(segments, "-"); i >= 2 {
+		repoEnd, pageStart = i, i+1
+	} else {
+		for i, segment := range segments {
+			if _, ok := webPageKinds[segment]; ok && i >= 2 {
+				repoEnd, pageStart = i, i
+				break
+			}
+		}
+	}
+	if repoEnd < 2 {
+		return nil, fmt.Errorf("invalid web URL %q: no owner/repository path", rawURL)
+	}
+	location.RepoPath = strings.TrimSuffix(strings.Join(segments[:repoEnd], "/"), ".git")
+	location.Owner = strings.Join(segments[:repoEnd-1], "/")
+	location.Repo = strings.TrimSuffix(segments[repoEnd-1], ".git")
+
+	if pageStart < len(segments) {
+		kind, ok := webPageKinds[segments[pageStart]]
+		if !ok {
+			return nil, fmt.Errorf("unsupported web URL %q: unknown page %q", rawURL, segments[pageStart])
+		}
+		location.Kind = kind
+		location.Rest = segments[pageStart+1:]
+		if len(location.Rest) == 0 {
+			return nil, fmt.Errorf("invalid web URL %q: missing ref or commit", rawURL)
+		}
+	}
+
+	if m := lineFragmentPattern.FindStringSubmatch(parsed.Fragment); m != nil {
+		location.Line, _ = strconv.Atoi(m[1])
+	}
+	return location, nil
+}
+
+// hasDotSegment reports whether any of segments is "." or "..".
+func hasDotSegment(segments []string) bool {
+	for _, segment := range segments {
+		if segment == "." || segment == ".." {
+			return true
+		}
+	}
+	return false
+}
+
+// indexOfSegment returns the index of the first segment equal to name, or -1.
+func indexOfSegment(segments []string, name string) int {
+	for i, segment := range segments {
+		if segment == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// parseAzureWebURL parses the Azure DevOps layout: [org/]project/_git/repo,
+// with files selected by the "path", "version" and "line" query parameters
+// and commits at .../_git/repo/commit/<sha>.
+func parseAzureWebURL(location *webLocation, segments []string, gitIndex int, parsed *url.URL) (*webLocation, error) {
+	location.Style = azureStyle
+	location.RepoPath = strings.Join(segments[:gitIndex+2], "/")
+	location.Owner = strings.Join(segments[:gitIndex], "/")
+	location.Repo = segments[gitIndex+1]
+
+	query := parsed.Query()
+	rest := segments[gitIndex+2:]
+	switch {
+	case len(rest) >= 2 && rest[0] == "commit":
+		location.Kind = commitLocation
+		location.Rest = rest[1:2]
+	case query.Get("path") != "":
+		// Versions are prefixed with their type: GB (branch), GT (tag) or GC (commit)
+		ref := query.Get("version")
+		if len(ref) > 2 {
+			ref = ref[2:]
+		}
+		location.Kind = pathLocation
+		location.Rest = append([]string{ref}, strings.FieldsFunc(query.Get("path"), func(r rune) bool { return r == '/' })...)
+		if hasDotSegment(location.Rest[1:]) {
+			return nil, fmt.Errorf("invalid web URL %q: \".\" and \"..\" path segments are not allowed", parsed)
+		}
+		location.Line, _ = strconv.Atoi(query.Get("line"))
+	}
+	return location, nil
+}
+
+// parseBitbucketServerWebURL parses the Bitbucket Server layout:
+// projects/KEY/repos/slug (or users/name/repos/slug for personal
+// repositories), with files at .../browse/<path>?at=<ref> and commits at
+// .../commits/<sha>.
+func parseBitbucketServerWebURL(location *webLocation, segments []string, parsed *url.URL) (*webLocation, error) {
+	location.Style = bitbucketServerStyle
+	location.RepoPath = strings.Join(segments[:4], "/")
+	location.Owner = segments[1]
+	if segments[0] == "users" {
+		location.Owner = "~" + segments[1]
+	}
+	location.Repo = segments[3]
+
+	rest := segments[4:]
+	switch {
+	case len(rest) >= 2 && rest[0] == "commits":
+		location.Kind = commitLocation
+		location.Rest = rest[1:2]
+	case len(rest) >= 1 && rest[0] == "browse":
+		ref := strings.TrimPrefix(parsed.Query().Get("at"), "refs/heads/")
+		location.Kind = pathLocation
+		location.Rest = append([]string{ref}, rest[1:]...)
+		location.Line, _ = strconv.Atoi(strings.SplitN(parsed.Fragment, "-", 2)[0])
+	}
+	return location, nil
+}
+
+// bitbucketServerSSHPort is the default SSH port of Bitbucket Server.
+const bitbucketServerSSHPort = "7999"
+
+// cloneURL returns the SSH or HTTPS clone URL of the repository at location.
+func cloneURL(location *webLocation, ssh bool) string {
+	hostname := hostWithoutPort(location.Host)
+
+	switch location.Style {
+	case azureStyle:
+		// dev.azure.com/org/project/_git/repo, or the legacy
+		// org.visualstudio.com/[DefaultCollection/]project/_git/repo
+		// Project and repository names may contain spaces
+		org, project, _ := strings.Cut(location.Owner, "/")
+		repo := url.PathEscape(location.Repo)
+		if strings.HasSuffix(hostname, ".visualstudio.com") {
+			project = strings.TrimPrefix(location.Owner, "DefaultCollection/")
+			org = strings.TrimSuffix(hostname, ".visualstudio.com")
+			if ssh {
+				return fmt.Sprintf("%s@vs-ssh.visualstudio.com:v3/%s/%s/%s", org, org, url.PathEscape(project), repo)
+			}
+		} else if ssh {
+			return fmt.Sprintf("git@ssh.%s:v3/%s/%s/%s", hostname, org, url.PathEscape(project), repo)
+		}
+		return (&url.URL{Scheme: "https", Host: location.Host, Path: "/" + location.RepoPath}).String()
+	case bitbucketServerStyle:
+		path := strings.ToLower(location.Owner) + "/" + location.Repo + ".git"
+		if ssh {
+			return fmt.Sprintf("ssh://git@%s:%s/%s", hostname, bitbucketServerSSHPort, path)
+		}
+		return fmt.Sprintf("https://%s/scm/%s", location.Host, path)
+	default:
+		if ssh {
+			return fmt.Sprintf("git@%s:%s.git", hostname, location.RepoPath)
+		}
+		return fmt.Sprintf("https://%s/%s.git", location.Host, location.RepoPath)
+	}
+}
+
+// hostWithoutPort strips the port, if any, from host.
+func hostWithoutPort(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		return h
+	}
+	return host
+}
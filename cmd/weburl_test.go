@@ -0,0 +1,194 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_parseWebURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		rawURL  string
+		want    *webLocation
+		wantErr bool
+	}{
+		{
+			name:   "github repository",
+			rawURL: "https://github.com/zhaochunqi/git-open",
+			want:   &webLocation{Host: "github.com", RepoPath: "zhaochunqi/git-open", Owner: "zhaochunqi", Repo: "git-open"},
+		},
+		{
+			name:   "github repository with .git suffix and trailing slash",
+			rawURL: "https://github.com/zhaochunqi/git-open.git/",
+			want:   &webLocation{Host: "github.com", RepoPath: "zhaochunqi/git-open", Owner: "zhaochunqi", Repo: "git-open"},
+		},
+		{
+			name:   "github blob with line",
+			rawURL: "https://github.com/zhaochunqi/git-open/blob/main/cmd/git.go#L10",
+			want:   &webLocation{Host: "github.com", RepoPath: "zhaochunqi/git-open", Owner: "zhaochunqi", Repo: "git-open", Kind: pathLocation, Rest: []string{"main", "cmd", "git.go"}, Line: 10},
+		},
+		{
+			name:   "github blob with line range",
+			rawURL: "https://github.com/zhaochunqi/git-open/blob/main/README.md#L3-L8",
+			want:   &webLocation{Host: "github.com", RepoPath: "zhaochunqi/git-open", Owner: "zhaochunqi", Repo: "git-open", Kind: pathLocation, Rest: []string{"main", "README.md"}, Line: 3},
+		},
+		{
+			name:   "github tree",
+			rawURL: "https://github.com/zhaochunqi/git-open/tree/feat/x/cmd",
+			want:   &webLocation{Host: "github.com", RepoPath: "zhaochunqi/git-open", Owner: "zhaochunqi", Repo: "git-open", Kind: pathLocation, Rest: []string{"feat", "x", "cmd"}},
+		},
+		{
+			name:   "github commit",
+			rawURL: "https://github.com/zhaochunqi/git-open/commit/0123abc",
+			want:   &webLocation{Host: "github.com", RepoPath: "zhaochunqi/git-open", Owner: "zhaochunqi", Repo: "git-open", Kind: commitLocation, Rest: []string{"0123abc"}},
+		},
+		{
+			name:   "gitlab nested groups blob",
+			rawURL: "https://gitlab.com/group/sub/project/-/blob/main/src/app.go#L42",
+			want:   &webLocation{Host: "gitlab.com", RepoPath: "group/sub/project", Owner: "group/sub", Repo: "project", Kind: pathLocation, Rest: []string{"main", "src", "app.go"}, Line: 42},
+		},
+		{
+			name:   "gitlab commit",
+			rawURL: "https://gitlab.com/group/project/-/commit/0123abc",
+			want:   &webLocation{Host: "gitlab.com", RepoPath: "group/project", Owner: "group", Repo: "project", Kind: commitLocation, Rest: []string{"0123abc"}},
+		},
+		{
+			name:   "bitbucket src with lines",
+			rawURL: "https://bitbucket.org/owner/repo/src/main/docs/guide.md#lines-7:9",
+			want:   &webLocation{Host: "bitbucket.org", RepoPath: "owner/repo", Owner: "owner", Repo: "repo", Kind: pathLocation, Rest: []string{"main", "docs", "guide.md"}, Line: 7},
+		},
+		{
+			name:   "bitbucket commits",
+			rawURL: "https://bitbucket.org/owner/repo/commits/0123abc",
+			want:   &webLocation{Host: "bitbucket.org", RepoPath: "owner/repo", Owner: "owner", Repo: "repo", Kind: commitLocation, Rest: []string{"0123abc"}},
+		},
+		{
+			name:   "self-hosted with port",
+			rawURL: "http://git.example.com:8080/team/service/blob/v1.2.3/main.go",
+			want:   &webLocation{Host: "git.example.com:8080", RepoPath: "team/service", Owner: "team", Repo: "service", Kind: pathLocation, Rest: []string{"v1.2.3", "main.go"}},
+		},
+		{
+			name:   "azure devops repository",
+			rawURL: "https://dev.azure.com/org/My%20Project/_git/repo",
+			want:   &webLocation{Host: "dev.azure.com", RepoPath: "org/My Project/_git/repo", Owner: "org/My Project", Repo: "repo", Style: azureStyle},
+		},
+		{
+			name:   "azure devops file",
+			rawURL: "https://dev.azure.com/org/project/_git/repo?path=/src/app.go&version=GBfeature/x&line=12",
+			want:   &webLocation{Host: "dev.azure.com", RepoPath: "org/project/_git/repo", Owner: "org/project", Repo: "repo", Style: azureStyle, Kind: pathLocation, Rest: []string{"feature/x", "src", "app.go"}, Line: 12},
+		},
+		{
+			name:   "azure devops commit",
+			rawURL: "https://dev.azure.com/org/project/_git/repo/commit/0123abc",
+			want:   &webLocation{Host: "dev.azure.com", RepoPath: "org/project/_git/repo", Owner: "org/project", Repo: "repo", Style: azureStyle, Kind: commitLocation, Rest: []string{"0123abc"}},
+		},
+		{
+			name:   "bitbucket server file",
+			rawURL: "https://bitbucket.example.com/projects/PROJ/repos/service/browse/src/app.go?at=refs/heads/develop#25",
+			want:   &webLocation{Host: "bitbucket.example.com", RepoPath: "projects/PROJ/repos/service", Owner: "PROJ", Repo: "service", Style: bitbucketServerStyle, Kind: pathLocation, Rest: []string{"develop", "src", "app.go"}, Line: 25},
+		},
+		{
+			name:   "bitbucket server personal repository commit",
+			rawURL: "https://bitbucket.example.com/users/jdoe/repos/dotfiles/commits/0123abc",
+			want:   &webLocation{Host: "bitbucket.example.com", RepoPath: "users/jdoe/repos/dotfiles", Owner: "~jdoe", Repo: "dotfiles", Style: bitbucketServerStyle, Kind: commitLocation, Rest: []string{"0123abc"}},
+		},
+		{
+			name:   "gitlab subgroups named like pages",
+			rawURL: "https://gitlab.com/group/tree/blob/project/-/tree/main/src",
+			want:   &webLocation{Host: "gitlab.com", RepoPath: "group/tree/blob/project", Owner: "group/tree/blob", Repo: "project", Kind: pathLocation, Rest: []string{"main", "src"}},
+		},
+		{name: "parent directory segments", rawURL: "https://github.com/zhaochunqi/git-open/blob/main/../../../etc/passwd", wantErr: true},
+		{name: "escaped parent directory segments", rawURL: "https://github.com/zhaochunqi/git-open/blob/main/%2e%2e/%2e%2e/etc/passwd", wantErr: true},
+		{name: "azure devops parent directory path", rawURL: "https://dev.azure.com/org/project/_git/repo?path=/../../etc/passwd&version=GBmain", wantErr: true},
+		{name: "not http", rawURL: "git@github.com:zhaochunqi/git-open.git", wantErr: true},
+		{name: "owner only", rawURL: "https://github.com/zhaochunqi", wantErr: true},
+		{name: "unknown gitlab page", rawURL: "https://gitlab.com/group/project/-/settings", wantErr: true},
+		{name: "blob without ref", rawURL: "https://github.com/zhaochunqi/git-open/blob", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseWebURL(tt.rawURL)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseWebURL(%q) error = %v, wantErr %v", tt.rawURL, err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseWebURL(%q) = %+v, want %+v", tt.rawURL, got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_webLocation_Name(t *testing.T) {
+	location := &webLocation{Host: "gitlab.com", RepoPath: "group/sub/project"}
+	if got, want := location.Name(), "gitlab.com/group/sub/project"; got != want {
+		t.Errorf("webLocation.Name() = %q, want %q", got, want)
+	}
+}
+
+func Test_cloneURL(t *testing.T) {
+	tests := []struct {
+		name      string
+		rawURL    string
+		wantSSH   string
+		wantHTTPS string
+	}{
+		{
+			name:      "github",
+			rawURL:    "https://github.com/zhaochunqi/git-open/blob/main/README.md",
+			wantSSH:   "git@github.com:zhaochunqi/git-open.git",
+			wantHTTPS: "https://github.com/zhaochunqi/git-open.git",
+		},
+		{
+			name:      "gitlab nested groups",
+			rawURL:    "https://gitlab.com/group/sub/project/-/tree/main",
+			wantSSH:   "git@gitlab.com:group/sub/project.git",
+			wantHTTPS: "https://gitlab.com/group/sub/project.git",
+		},
+		{
+			name:      "self-hosted with port",
+			rawURL:    "http://git.example.com:8080/team/service",
+			wantSSH:   "git@git.example.com:team/service.git",
+			wantHTTPS: "https://git.example.com:8080/team/service.git",
+		},
+		{
+			name:      "azure devops",
+			rawURL:    "https://dev.azure.com/org/My%20Project/_git/repo?path=/README.md",
+			wantSSH:   "git@ssh.dev.azure.com:v3/org/My%20Project/repo",
+			wantHTTPS: "https://dev.azure.com/org/My%20Project/_git/repo",
+		},
+		{
+			name:      "azure devops legacy host",
+			rawURL:    "https://org.visualstudio.com/DefaultCollection/project/_git/repo",
+			wantSSH:   "org@vs-ssh.visualstudio.com:v3/org/project/repo",
+			wantHTTPS: "https://org.visualstudio.com/DefaultCollection/project/_git/repo",
+		},
+		{
+			name:      "bitbucket server",
+			rawURL:    "https://bitbucket.example.com/projects/PROJ/repos/service/browse",
+			wantSSH:   "ssh://git@bitbucket.example.com:7999/proj/service.git",
+			wantHTTPS: "https://bitbucket.example.com/scm/proj/service.git",
+		},
+		{
+			name:      "bitbucket server personal repository",
+			rawURL:    "https://bitbucket.example.com/users/jdoe/repos/dotfiles",
+			wantSSH:   "ssh://git@bitbucket.example.com:7999/~jdoe/dotfiles.git",
+			wantHTTPS: "https://bitbucket.example.com/scm/~jdoe/dotfiles.git",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			location, err := parseWebURL(tt.rawURL)
+			if err != nil {
+				t.Fatalf("parseWebURL(%q) error = %v", tt.rawURL, err)
+			}
+			if got := cloneURL(location, true); got != tt.wantSSH {
+				t.Errorf("cloneURL(ssh) = %q, want %q", got, tt.wantSSH)
+			}
+			if got := cloneURL(location, false); got != tt.wantHTTPS {
+				t.Errorf("cloneURL(https) = %q, want %q", got, tt.wantHTTPS)
+			}
+		})
+	}
+}
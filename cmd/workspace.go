@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/zhaochunqi/git-open/internal/homedir"
+	"github.com/zhaochunqi/git-open/pkg/gitopen"
+)
+
+// Workspaces holds the directories from the "workspaces" config key that are
+// searched for local clones.
+var Workspaces []string
+
+// maxWorkspaceDepth limits how far below a workspace root repositories are
+// searched for, e.g. ~/src/<host>/<owner>/<repo> is three levels deep.
+const maxWorkspaceDepth = 4
+
+// findRepositories returns the working tree roots of the Git repositories
+// below root, at most maxDepth levels deep. It does not descend into
+// repositories or hidden directories.
+func findRepositories(root string, maxDepth int) ([]string, error) {
+	root = filepath.Clean(root)
+	var repos []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == root {
+				return err
+			}
+			// Skip directories that cannot be read
+			return nil
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != root && strings.HasPrefix(d.Name(), ".") {
+			return fs.SkipDir
+		}
+		if _, err := os.Lstat(filepath.Join(path, git.GitDirName)); err == nil {
+			repos = append(repos, path)
+			return fs.SkipDir
+		}
+		if rel, _ := filepath.Rel(root, path); rel != "." && strings.Count(rel, string(filepath.Separator)) >= maxDepth-1 {
+			return fs.SkipDir
+		}
+		return nil
+	})
+	return repos, err
+}
+
+// repositoryKey returns the name under which the web URL and the clone URLs of
+// a repository match: host/owner/repo, lower-cased and without the port.
+func repositoryKey(host, owner, repo string) string {
+	return strings.ToLower(hostWithoutPort(host) + "/" + owner + "/" + repo)
+}
+
+// remoteRepositoryKeys returns the repositoryKeys remoteURL may stand for. The
+// clone URLs of some forges differ in shape from their web URLs: Bitbucket
+// Server serves HTTPS clones below /scm, and Azure DevOps clones over HTTPS
+// from org/project/_git/repo and over SSH from ssh.<host>:v3/org/project/repo.
+func remoteRepositoryKeys(remoteURL string) []string {
+	remote, err := gitopen.ParseRemoteURL(remoteURL)
+	if err != nil {
+		return nil
+	}
+	host, owner, repo := remote.Host, remote.Owner, remote.Repo
+	keys := []string{repositoryKey(host, owner, repo)}
+	if project, ok := strings.CutPrefix(owner, "scm/"); ok {
+		keys = append(keys, repositoryKey(host, project, repo))
+	}
+	if project, ok := strings.CutSuffix(owner, "/_git"); ok {
+		keys = append(keys, repositoryKey(host, project, repo))
+	}
+	if project, ok := strings.CutPrefix(owner, "v3/"); ok {
+		keys = append(keys, repositoryKey(strings.TrimPrefix(host, "ssh."), project, repo))
+	}
+	return keys
+}
+
+// remoteURLs returns the URLs of the remotes of repo, rewritten by the
+// url.<base>.insteadOf rules of its effective config, or as go-git reads them
+// when that cannot be read.
+func remoteURLs(repo *git.Repository) []string {
+	var urls []string
+	if gitDir := gitopen.GitDir(repo); gitDir != "" {
+		if fast, err := newFastRepository(gitDir, false); err == nil {
+			for _, name := range fast.config.RemoteNames() {
+				for _, remoteURL := range fast.config.GetAll("remote", name, "url") {
+					urls = append(urls, resolveSSHAlias(fast.config.RewriteURL(remoteURL)))
+				}
+			}
+			return urls
+		}
+	}
+	remotes, err := repo.Remotes()
+	if err != nil {
+		return nil
+	}
+	for _, remote := range remotes {
+		for _, remoteURL := range remote.Config().URLs {
+			urls = append(urls, resolveSSHAlias(remoteURL))
+		}
+	}
+	return urls
+}
+
+// repositoryHasRemote reports whether any remote URL of repo leads to the
+// repository at location.
+func repositoryHasRemote(repo *git.Repository, location *webLocation) bool {
+	key := repositoryKey(location.Host, location.Owner, location.Repo)
+	for _, remoteURL := range remoteURLs(repo) {
+		for _, remoteKey := range remoteRepositoryKeys(remoteURL) {
+			if remoteKey == key {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// findLocalClone returns the working tree root of a local clone of the
+// repository at location, looking at the repository in the current working
+// directory first and then below each workspace root.
+func findLocalClone(location *webLocation, workspaces []string) (string, *git.Repository, error) {
+	if repo, err := openRepository("."); err == nil && repositoryHasRemote(repo, location) {
+		if root, err := worktreeRoot(repo); err == nil {
+			return root, repo, nil
+		}
+	}
+
+	for _, workspace := range workspaces {
+		candidates, err := findRepositories(homedir.Expand(workspace), maxWorkspaceDepth)
+		if err != nil {
+			continue
+		}
+		for _, candidate := range candidates {
+			repo, err := openRepository(candidate)
+			if err == nil && repositoryHasRemote(repo, location) {
+				return candidate, repo, nil
+			}
+		}
+	}
+	return "", nil, fmt.Errorf("no local clone of %s found in workspaces %v", location.Name(), workspaces)
+}
+
+// worktreeRoot returns the root directory of repo's working tree.
+func worktreeRoot(repo *git.Repository) (string, error) {
+	wt, err := repo.Worktree()
+	if err != nil {
+		return "", err
+	}
+	return wt.Filesystem.Root(), nil
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+)
+
+// initRepoWithRemote initialises a repository at path with an origin remote.
+func initRepoWithRemote(t *testing.T, path, remoteURL string) {
+	t.Helper()
+	repo, err := git.PlainInit(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.CreateRemote(&config.RemoteConfig{Name: "origin", URLs: []string{remoteURL}}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_findRepositories(t *testing.T) {
+	root := t.TempDir()
+	initRepoWithRemote(t, filepath.Join(root, "github.com", "owner", "a"), "https://github.com/owner/a.git")
+	initRepoWithRemote(t, filepath.Join(root, "b"), "https://github.com/owner/b.git")
+	// Nested repositories and hidden directories are not searched.
+	initRepoWithRemote(t, filepath.Join(root, "b", "vendor", "nested"), "https://github.com/owner/nested.git")
+	initRepoWithRemote(t, filepath.Join(root, ".cache", "hidden"), "https://github.com/owner/hidden.git")
+	// Too deep to be found.
+	initRepoWithRemote(t, filepath.Join(root, "1", "2", "3", "4", "deep"), "https://github.com/owner/deep.git")
+
+	got, err := findRepositories(root, maxWorkspaceDepth)
+	if err != nil {
+		t.Fatalf("findRepositories() error = %v", err)
+	}
+	want := []string{filepath.Join(root, "b"), filepath.Join(root, "github.com", "owner", "a")}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("findRepositories() = %q, want %q", got, want)
+	}
+
+	if _, err := findRepositories(filepath.Join(root, "missing"), maxWorkspaceDepth); err == nil {
+		t.Error("findRepositories() expected error for missing root, got nil")
+	}
+}
+
+func Test_findLocalClone(t *testing.T) {
+	workspace := t.TempDir()
+	initRepoWithRemote(t, filepath.Join(workspace, "a"), "git@github.com:owner/a.git")
+	initRepoWithRemote(t, filepath.Join(workspace, "b"), "https://gitlab.com/Group/Sub/B.git")
+	initRepoWithRemote(t, filepath.Join(workspace, "c"), "ssh://git@bitbucket.example.com:7999/proj/service.git")
+	initRepoWithRemote(t, filepath.Join(workspace, "d"), "https://bitbucket.example.com/scm/~alice/tool.git")
+	initRepoWithRemote(t, filepath.Join(workspace, "e"), "gh:owner/e")
+	appendGitConfig(t, filepath.Join(workspace, "e"), "[url \"git@github.com:\"]\n\tinsteadOf = gh:\n")
+
+	// Run from outside any repository.
+	origDir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(origDir) })
+
+	tests := []struct {
+		name    string
+		repo    string
+		want    string
+		wantErr bool
+	}{
+		{"ssh remote", "https://github.com/owner/a", filepath.Join(workspace, "a"), false},
+		{"case insensitive", "https://gitlab.com/group/sub/b/-/blob/main/README.md", filepath.Join(workspace, "b"), false},
+		{"Bitbucket Server ssh remote", "https://bitbucket.example.com/projects/PROJ/repos/service/browse/README.md", filepath.Join(workspace, "c"), false},
+		{"Bitbucket Server personal repository", "https://bitbucket.example.com/users/alice/repos/tool", filepath.Join(workspace, "d"), false},
+		{"insteadOf rewrite", "https://github.com/owner/e", filepath.Join(workspace, "e"), false},
+		{"no clone", "https://github.com/owner/missing", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			location, err := parseWebURL(tt.repo)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, _, err := findLocalClone(location, []string{filepath.Join(workspace, "missing"), workspace})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("findLocalClone() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("findLocalClone() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
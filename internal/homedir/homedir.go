@@ -0,0 +1,20 @@
+// Package homedir expands paths relative to the user's home directory.
+package homedir
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Expand replaces a leading "~" in path with the user's home directory.
+func Expand(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") && !strings.HasPrefix(path, `~\`) {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[1:])
+}
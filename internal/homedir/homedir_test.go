@@ -0,0 +1,27 @@
+package homedir
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestExpand(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"~", home},
+		{"~/src", filepath.Join(home, "src")},
+		{"/opt/src", "/opt/src"},
+		{"~other/src", "~other/src"},
+	}
+	for _, tt := range tests {
+		if got := Expand(tt.path); got != tt.want {
+			t.Errorf("Expand(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
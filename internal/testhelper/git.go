@@ -15,7 +15,7 @@ import (
 
 // SetupTestRepo creates a temporary git repository for testing.
 // It returns the temporary directory path and a cleanup function.
-func SetupTestRepo(t *testing.T, remoteURL string, branchName string) (string, func()) {
+func SetupTestRepo(t testing.TB, remoteURL string, branchName string) (string, func()) {
 	t.Helper()
 
 	// Create temporary directory
@@ -255,3 +255,75 @@ func SetupTestRepoWithoutCommit(t *testing.T, remoteURL string, branchName strin
 
 	return tmpDir, cleanup
 }
+
+// SetupTestSubmodule creates a superproject with remote superRemoteURL and a
+// submodule at "lib" whose .gitmodules URL is submoduleURL (which may be
+// relative, e.g. "../lib.git"), synced to the superproject's config, and whose
+// own origin is submoduleRemoteURL. It
+// changes into the submodule and returns the superproject and submodule
+// directories and a cleanup function.
+func SetupTestSubmodule(t *testing.T, superRemoteURL, submoduleURL, submoduleRemoteURL string) (string, string, func()) {
+	t.Helper()
+
+	baseDir, err := os.MkdirTemp("", "git-test-submodule")
+	if err != nil {
+		t.Fatal(err)
+	}
+	baseDir, err = filepath.EvalSymlinks(baseDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	runGit := func(dir string, args ...string) {
+		t.Helper()
+		args = append([]string{
+			"-c", "user.name=Test User",
+			"-c", "user.email=test@example.com",
+			"-c", "protocol.file.allow=always",
+			"-c", "init.defaultBranch=main",
+		}, args...)
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			os.RemoveAll(baseDir)
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+
+	// The submodule is added from a local repository, then pointed at its
+	// real remote.
+	libDir := filepath.Join(baseDir, "lib-source")
+	superDir := filepath.Join(baseDir, "super")
+	for _, dir := range []string{libDir, superDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		runGit(dir, "init", "-q")
+		runGit(dir, "commit", "-q", "--allow-empty", "-m", "initial commit")
+	}
+
+	runGit(superDir, "remote", "add", "origin", superRemoteURL)
+	runGit(superDir, "submodule", "add", "-q", libDir, "lib")
+	runGit(superDir, "config", "-f", ".gitmodules", "submodule.lib.url", submoduleURL)
+	runGit(superDir, "commit", "-q", "-am", "add submodule")
+	// Like after a clone, the superproject's config holds the URL too
+	runGit(superDir, "submodule", "sync", "-q")
+
+	subDir := filepath.Join(superDir, "lib")
+	runGit(subDir, "remote", "set-url", "origin", submoduleRemoteURL)
+
+	currentDir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(subDir); err != nil {
+		t.Fatal(err)
+	}
+
+	cleanup := func() {
+		os.Chdir(currentDir)
+		os.RemoveAll(baseDir)
+	}
+
+	return superDir, subDir, cleanup
+}
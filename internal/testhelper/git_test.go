@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
 	"github.com/go-git/go-git/v5/plumbing"
 )
 
@@ -373,3 +374,45 @@ func samePath(p1, p2 string) bool {
 	}
 	return p1Eval == p2Eval
 }
+
+func TestSetupTestSubmodule(t *testing.T) {
+	superDir, subDir, cleanup := SetupTestSubmodule(t, "https://github.com/test/super.git", "../lib.git", "https://github.com/test/lib.git")
+	defer cleanup()
+
+	currentDir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !samePath(currentDir, subDir) {
+		t.Errorf("SetupTestSubmodule() current directory = %s, want %s", currentDir, subDir)
+	}
+
+	// The submodule's .git is a gitdir file pointing into the superproject.
+	if fi, err := os.Lstat(filepath.Join(subDir, ".git")); err != nil || fi.IsDir() {
+		t.Errorf("SetupTestSubmodule() submodule .git is not a file: %v", err)
+	}
+
+	if _, err := git.PlainOpen(superDir); err != nil {
+		t.Fatalf("SetupTestSubmodule() did not create a valid superproject: %v", err)
+	}
+	b, err := os.ReadFile(filepath.Join(superDir, ".gitmodules"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	modules := config.NewModules()
+	if err := modules.Unmarshal(b); err != nil {
+		t.Fatal(err)
+	}
+	if lib := modules.Submodules["lib"]; lib == nil || lib.Path != "lib" || lib.URL != "../lib.git" {
+		t.Errorf("SetupTestSubmodule() .gitmodules = %s, want lib with URL ../lib.git", b)
+	}
+
+	sub, err := git.PlainOpenWithOptions(subDir, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		t.Fatalf("SetupTestSubmodule() did not create a valid submodule: %v", err)
+	}
+	remote, err := sub.Remote("origin")
+	if err != nil || remote.Config().URLs[0] != "https://github.com/test/lib.git" {
+		t.Errorf("SetupTestSubmodule() submodule origin = %v, %v", remote, err)
+	}
+}
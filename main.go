@@ -17,7 +17,10 @@ func main() {
 func runApp() error {
 	if err := cmd.Execute(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err) // Print error to stderr
-		exitFunc(1)
+		if hint := cmd.Hint(err); hint != "" {
+			fmt.Fprintf(os.Stderr, "Hint: %s\n", hint)
+		}
+		exitFunc(cmd.ExitCode(err))
 		return err // Return error for testing purposes
 	}
 	return nil
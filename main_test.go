@@ -3,7 +3,9 @@ package main
 import (
 	"bytes"
 	"errors"
+	"fmt"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/zhaochunqi/git-open/cmd"
@@ -48,6 +50,7 @@ func Test_runApp(t *testing.T) {
 		wantURL    string
 		executeErr error // New field to simulate cmd.Execute error
 		wantErr    bool  // Expect runApp to return an error
+		wantCode   int   // Expected exit code when runApp fails
 	}{
 		{
 			name:       "default behavior",
@@ -62,6 +65,15 @@ func Test_runApp(t *testing.T) {
 			wantURL:    "", // Not relevant for this test
 			executeErr: errors.New("mock execute error"),
 			wantErr:    true,
+			wantCode:   1,
+		},
+		{
+			name:       "not a repository",
+			args:       []string{"git-open"},
+			wantURL:    "",
+			executeErr: fmt.Errorf("error getting git directory: %w", cmd.ErrNotRepository),
+			wantErr:    true,
+			wantCode:   2,
 		},
 	}
 
@@ -126,9 +138,12 @@ func Test_runApp(t *testing.T) {
 				t.Errorf("OpenURLInBrowser called with %v, want %v", openedURL, tt.wantURL)
 			}
 
-			// For error case, check if os.Exit was called with 1
-			if tt.wantErr && actualExitCode != 1 {
-				t.Errorf("Expected os.Exit(1) to be called, but got %v", actualExitCode)
+			// For error case, check os.Exit was called with the error's code
+			if tt.wantErr && actualExitCode != tt.wantCode {
+				t.Errorf("Expected os.Exit(%d) to be called, but got %v", tt.wantCode, actualExitCode)
+			}
+			if tt.wantErr && tt.wantCode != 1 && !strings.Contains(output, "Hint: ") {
+				t.Errorf("Expected a hint for %v, got output %q", tt.executeErr, output)
 			}
 		})
 	}
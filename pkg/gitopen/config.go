@@ -0,0 +1,610 @@
+package gitopen
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/zhaochunqi/git-open/internal/homedir"
+)
+
+// maxConfigIncludeDepth is how deeply config files may include each other, as
+// in git.
+const maxConfigIncludeDepth = 10
+
+// configEntry is one "key = value" line of a git config file.
+type configEntry struct {
+	// section and key are lower-case, as they are case-insensitive.
+	section    string
+	subsection string
+	key        string
+	value      string
+}
+
+// Config is a git configuration read directly from config files, without
+// go-git, following include.path and includeIf.<condition>.path. Unlike
+// go-git's, it includes the system and global layers.
+type Config struct {
+	entries []configEntry
+	// logger is nil when nothing is traced.
+	logger *slog.Logger
+}
+
+// configIncludeContext is what conditional includes are evaluated against.
+type configIncludeContext struct {
+	// gitDir is the absolute path of the repository's git directory.
+	gitDir string
+	// branch is the short name of the branch HEAD points to.
+	branch string
+	// remoteURLs are the remote URLs of the whole configuration, for
+	// hasconfig:remote.*.url conditions.
+	remoteURLs []string
+	// sawHasconfig is set when a hasconfig condition was evaluated.
+	sawHasconfig bool
+}
+
+// ReadConfig reads the effective configuration of the repository with the
+// given git and common directories (see CommonGitDir), layering the system,
+// global, repository and worktree config files like git does.
+func ReadConfig(gitDir, commonDir string, logger *slog.Logger) (*Config, error) {
+	ctx := &configIncludeContext{gitDir: gitDir, branch: HeadBranch(gitDir)}
+	cfg, err := readConfigLayers(gitDir, commonDir, ctx, logger)
+	if err != nil || !ctx.sawHasconfig {
+		return cfg, err
+	}
+
+	// hasconfig:remote.*.url conditions depend on the remote URLs of the
+	// whole configuration, so read it again now that they are known
+	ctx.remoteURLs = cfg.remoteURLs()
+	return readConfigLayers(gitDir, commonDir, ctx, logger)
+}
+
+// ParseConfig parses the contents of a single config file, without following
+// its includes.
+func ParseConfig(src string) (*Config, error) {
+	entries, err := parseGitConfig(src)
+	if err != nil {
+		return nil, err
+	}
+	return &Config{entries: entries}, nil
+}
+
+// readConfigLayers reads each config layer of a repository in turn.
+func readConfigLayers(gitDir, commonDir string, ctx *configIncludeContext, logger *slog.Logger) (*Config, error) {
+	cfg := &Config{logger: logger}
+
+	var optional []string
+	if path := systemConfigPath(); path != "" {
+		optional = append(optional, path)
+	}
+	optional = append(optional, globalConfigPaths()...)
+	for _, path := range optional {
+		if err := cfg.readFile(path, ctx, 0); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+	}
+
+	if err := cfg.readFile(filepath.Join(commonDir, "config"), ctx, 0); err != nil {
+		return nil, err
+	}
+
+	if worktreeConfig, _ := cfg.Get("extensions", "", "worktreeconfig"); ParseBool(worktreeConfig) {
+		err := cfg.readFile(filepath.Join(gitDir, "config.worktree"), ctx, 0)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+	}
+	return cfg, nil
+}
+
+// systemConfigPath returns the system config file, or "" when
+// GIT_CONFIG_NOSYSTEM is set.
+func systemConfigPath() string {
+	if ParseBool(os.Getenv("GIT_CONFIG_NOSYSTEM")) {
+		return ""
+	}
+	if path := os.Getenv("GIT_CONFIG_SYSTEM"); path != "" {
+		return path
+	}
+	return "/etc/gitconfig"
+}
+
+// globalConfigPaths returns the global config files in the order git reads
+// them: GIT_CONFIG_GLOBAL if set, otherwise $XDG_CONFIG_HOME/git/config
+// (defaulting to ~/.config/git/config) and then ~/.gitconfig.
+func globalConfigPaths() []string {
+	if path, ok := os.LookupEnv("GIT_CONFIG_GLOBAL"); ok {
+		if path == "" {
+			return nil
+		}
+		return []string{homedir.Expand(path)}
+	}
+
+	var paths []string
+	xdg := os.Getenv("XDG_CONFIG_HOME")
+	if xdg == "" {
+		xdg = homedir.Expand("~/.config")
+	}
+	if filepath.IsAbs(xdg) {
+		paths = append(paths, filepath.Join(xdg, "git", "config"))
+	}
+	if home := homedir.Expand("~"); home != "~" {
+		paths = append(paths, filepath.Join(home, ".gitconfig"))
+	}
+	return paths
+}
+
+// Get returns the last value of section.subsection.key, as git does for
+// single-valued keys. Section and key names are case-insensitive, subsection
+// names are not.
+func (c *Config) Get(section, subsection, key string) (string, bool) {
+	values := c.GetAll(section, subsection, key)
+	if len(values) == 0 {
+		return "", false
+	}
+	return values[len(values)-1], true
+}
+
+// GetAll returns every value of section.subsection.key in order.
+func (c *Config) GetAll(section, subsection, key string) []string {
+	section, key = strings.ToLower(section), strings.ToLower(key)
+	var values []string
+	for _, entry := range c.entries {
+		if entry.section == section && entry.subsection == subsection && entry.key == key {
+			values = append(values, entry.value)
+		}
+	}
+	return values
+}
+
+// Keys returns the lower-case names of the keys set in section.subsection, in
+// the order they are first set.
+func (c *Config) Keys(section, subsection string) []string {
+	section = strings.ToLower(section)
+	var keys []string
+	seen := make(map[string]bool)
+	for _, entry := range c.entries {
+		if entry.section == section && entry.subsection == subsection && !seen[entry.key] {
+			seen[entry.key] = true
+			keys = append(keys, entry.key)
+		}
+	}
+	return keys
+}
+
+// RemoteNames returns the names of the remotes that have a URL, in the order
+// they are configured.
+func (c *Config) RemoteNames() []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, entry := range c.entries {
+		if entry.section == "remote" && entry.key == "url" && !seen[entry.subsection] {
+			seen[entry.subsection] = true
+			names = append(names, entry.subsection)
+		}
+	}
+	return names
+}
+
+// trace returns the logger tracing which files are read and how URLs are
+// rewritten.
+func (c *Config) trace() *slog.Logger {
+	return orDiscard(c.logger)
+}
+
+// RemoteURL returns the first URL of the named remote, rewritten by any
+// matching url.<base>.insteadOf rule.
+func (c *Config) RemoteURL(name string) (string, bool) {
+	urls := c.GetAll("remote", name, "url")
+	if len(urls) == 0 || urls[0] == "" {
+		return "", false
+	}
+	return c.RewriteURL(urls[0]), true
+}
+
+// remoteURLs returns the URLs of every remote.
+func (c *Config) remoteURLs() []string {
+	var urls []string
+	for _, entry := range c.entries {
+		if entry.section == "remote" && entry.key == "url" {
+			urls = append(urls, entry.value)
+		}
+	}
+	return urls
+}
+
+// RewriteURL applies the url.<base>.insteadOf rule with the longest matching
+// prefix to rawURL.
+func (c *Config) RewriteURL(rawURL string) string {
+	var base, prefix string
+	for _, entry := range c.entries {
+		if entry.section != "url" || entry.key != "insteadof" {
+			continue
+		}
+		if strings.HasPrefix(rawURL, entry.value) && len(entry.value) > len(prefix) {
+			base, prefix = entry.subsection, entry.value
+		}
+	}
+	if prefix == "" {
+		return rawURL
+	}
+	c.trace().Debug("rewrote URL", "url", rawURL, "instead_of", prefix, "base", base)
+	return base + rawURL[len(prefix):]
+}
+
+// readFile appends the entries of the config file at path, and of the files it
+// includes, to c.
+func (c *Config) readFile(path string, ctx *configIncludeContext, depth int) error {
+	if depth > maxConfigIncludeDepth {
+		return fmt.Errorf("exceeded maximum include depth (%d) while including %s", maxConfigIncludeDepth, path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	entries, err := parseGitConfig(string(data))
+	if err != nil {
+		return fmt.Errorf("bad config file %s: %w", path, err)
+	}
+	c.trace().Debug("read git config", "path", path, "include_depth", depth)
+
+	for _, entry := range entries {
+		c.entries = append(c.entries, entry)
+		if entry.key != "path" {
+			continue
+		}
+
+		include := false
+		switch entry.section {
+		case "include":
+			include = entry.subsection == ""
+		case "includeif":
+			include = includeConditionMatches(entry.subsection, path, ctx)
+		}
+		if !include || entry.value == "" {
+			continue
+		}
+
+		// Missing included files are silently ignored, as in git
+		err := c.readFile(includePath(entry.value, path), ctx, depth+1)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// includePath resolves the path of an included file: "~/" is the home
+// directory and relative paths are relative to the including file.
+func includePath(path, from string) string {
+	path = homedir.Expand(path)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(filepath.Dir(from), path)
+	}
+	return path
+}
+
+// includeConditionMatches evaluates the condition of an includeIf section
+// found in the config file at from.
+func includeConditionMatches(condition, from string, ctx *configIncludeContext) bool {
+	kind, pattern, ok := strings.Cut(condition, ":")
+	if !ok || ctx == nil {
+		return false
+	}
+
+	switch kind {
+	case "gitdir", "gitdir/i":
+		if ctx.gitDir == "" {
+			return false
+		}
+		return gitdirMatches(gitdirPattern(pattern, from), ctx.gitDir, kind == "gitdir/i")
+	case "onbranch":
+		if ctx.branch == "" {
+			return false
+		}
+		if strings.HasSuffix(pattern, "/") {
+			pattern += "**"
+		}
+		return wildmatch(pattern, ctx.branch, false, true)
+	case "hasconfig":
+		urlPattern, ok := strings.CutPrefix(pattern, "remote.*.url:")
+		if !ok {
+			return false
+		}
+		ctx.sawHasconfig = true
+		for _, remoteURL := range ctx.remoteURLs {
+			if wildmatch(urlPattern, remoteURL, false, false) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// gitdirPattern expands an includeIf "gitdir:" pattern like git: "~/" is the
+// home directory, "./" the directory of the including file, other relative
+// patterns match at any depth, and a trailing "/" matches everything below.
+func gitdirPattern(pattern, from string) string {
+	// Joining paths drops the trailing slash, which is significant
+	trailingSlash := strings.HasSuffix(pattern, "/")
+	switch {
+	case strings.HasPrefix(pattern, "~/"):
+		pattern = homedir.Expand(pattern)
+	case strings.HasPrefix(pattern, "./"):
+		pattern = filepath.Join(filepath.Dir(from), pattern[len("./"):])
+	}
+	pattern = filepath.ToSlash(pattern)
+	if trailingSlash && !strings.HasSuffix(pattern, "/") {
+		pattern += "/"
+	}
+	if !strings.HasPrefix(pattern, "/") && !isWindowsDrivePath(pattern) {
+		pattern = "**/" + pattern
+	}
+	if strings.HasSuffix(pattern, "/") {
+		pattern += "**"
+	}
+	return pattern
+}
+
+// isWindowsDrivePath reports whether path starts with a drive letter, e.g. "C:/".
+func isWindowsDrivePath(path string) bool {
+	return len(path) >= 3 && path[1] == ':' && path[2] == '/'
+}
+
+// gitdirMatches reports whether the git directory gitDir, or the path it
+// resolves to, matches pattern.
+func gitdirMatches(pattern, gitDir string, foldCase bool) bool {
+	candidates := []string{gitDir}
+	if resolved, err := filepath.EvalSymlinks(gitDir); err == nil && resolved != gitDir {
+		candidates = append(candidates, resolved)
+	}
+	for _, candidate := range candidates {
+		if wildmatch(pattern, filepath.ToSlash(candidate), foldCase, true) {
+			return true
+		}
+	}
+	return false
+}
+
+// wildmatch reports whether text matches the git wildmatch pattern. With
+// pathname set, "*" does not match "/" but "**" does.
+func wildmatch(pattern, text string, foldCase, pathname bool) bool {
+	re, err := wildmatchRegexp(pattern, foldCase, pathname)
+	return err == nil && re.MatchString(text)
+}
+
+// wildmatchRegexp compiles a git wildmatch pattern to a regular expression.
+func wildmatchRegexp(pattern string, foldCase, pathname bool) (*regexp.Regexp, error) {
+	star, single := "[^/]*", "[^/]"
+	if !pathname {
+		star, single = ".*", "."
+	}
+
+	var re strings.Builder
+	if foldCase {
+		re.WriteString("(?i)")
+	}
+	re.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				i++
+				if i+1 < len(pattern) && pattern[i+1] == '/' {
+					// "**/" matches zero or more directories
+					i++
+					re.WriteString("(?:.*/)?")
+				} else {
+					re.WriteString(".*")
+				}
+			} else {
+				re.WriteString(star)
+			}
+		case '?':
+			re.WriteString(single)
+		case '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				re.WriteString(`\[`)
+				continue
+			}
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			re.WriteString("[" + class + "]")
+			i += end + 1
+		default:
+			re.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	re.WriteString("$")
+	return regexp.Compile(re.String())
+}
+
+// parseGitConfig parses the contents of a git config file.
+func parseGitConfig(src string) ([]configEntry, error) {
+	var entries []configEntry
+	var section, subsection string
+	haveSection := false
+	line := 1
+
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == '\n':
+			line++
+			i++
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case c == '#' || c == ';':
+			i = skipToEndOfLine(src, i)
+		case c == '[':
+			var err error
+			section, subsection, i, err = parseConfigSectionHeader(src, i+1)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			haveSection = true
+		case isConfigKeyChar(c):
+			if !haveSection {
+				return nil, fmt.Errorf("line %d: key outside of a section", line)
+			}
+			start := i
+			for i < len(src) && isConfigKeyChar(src[i]) {
+				i++
+			}
+			entry := configEntry{section: section, subsection: subsection, key: strings.ToLower(src[start:i])}
+			for i < len(src) && (src[i] == ' ' || src[i] == '\t' || src[i] == '\r') {
+				i++
+			}
+			switch {
+			case i < len(src) && src[i] == '=':
+				var err error
+				entry.value, i, err = parseConfigValue(src, i+1, &line)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: %w", line, err)
+				}
+			case i == len(src) || src[i] == '\n' || src[i] == '#' || src[i] == ';':
+				// A key without a value is a boolean true
+				entry.value = "true"
+			default:
+				return nil, fmt.Errorf("line %d: invalid key %q", line, src[start:i])
+			}
+			entries = append(entries, entry)
+		default:
+			return nil, fmt.Errorf("line %d: unexpected %q", line, c)
+		}
+	}
+	return entries, nil
+}
+
+func isConfigKeyChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-'
+}
+
+func skipToEndOfLine(src string, i int) int {
+	if end := strings.IndexByte(src[i:], '\n'); end >= 0 {
+		return i + end
+	}
+	return len(src)
+}
+
+// parseConfigSectionHeader parses a section header after its "[", returning
+// the section, the subsection and the index after the closing "]".
+func parseConfigSectionHeader(src string, i int) (string, string, int, error) {
+	start := i
+	for i < len(src) && (isConfigKeyChar(src[i]) || src[i] == '.') {
+		i++
+	}
+	name := strings.ToLower(src[start:i])
+	if name == "" {
+		return "", "", 0, errors.New("empty section name")
+	}
+	if i < len(src) && src[i] == ']' {
+		// The deprecated [section.subsection] syntax
+		section, subsection, _ := strings.Cut(name, ".")
+		return section, subsection, i + 1, nil
+	}
+
+	for i < len(src) && (src[i] == ' ' || src[i] == '\t') {
+		i++
+	}
+	if i >= len(src) || src[i] != '"' {
+		return "", "", 0, fmt.Errorf("invalid section header [%s", name)
+	}
+	var subsection strings.Builder
+	for i++; i < len(src) && src[i] != '"'; i++ {
+		if src[i] == '\n' {
+			return "", "", 0, errors.New("unterminated subsection name")
+		}
+		if src[i] == '\\' && i+1 < len(src) {
+			i++
+		}
+		subsection.WriteByte(src[i])
+	}
+	if i+1 >= len(src) || src[i+1] != ']' {
+		return "", "", 0, fmt.Errorf("invalid section header [%s", name)
+	}
+	return name, subsection.String(), i + 2, nil
+}
+
+// parseConfigValue parses a value after its "=", returning it and the index of
+// the end of its line. Surrounding whitespace is dropped, double quotes
+// preserve whitespace and comment characters, and a backslash escapes a quote,
+// a backslash, "n", "t", "b" or the end of the line.
+func parseConfigValue(src string, i int, line *int) (string, int, error) {
+	var value strings.Builder
+	var space strings.Builder
+	inQuote := false
+	started := false
+
+	write := func(s string) {
+		if started {
+			value.WriteString(space.String())
+		}
+		space.Reset()
+		value.WriteString(s)
+		started = true
+	}
+
+	for i < len(src) {
+		c := src[i]
+		switch {
+		case c == '\n':
+			if inQuote {
+				return "", 0, errors.New("unterminated quote")
+			}
+			return value.String(), i, nil
+		case !inQuote && (c == '#' || c == ';'):
+			return value.String(), skipToEndOfLine(src, i), nil
+		case c == ' ' || c == '\t' || c == '\r':
+			if inQuote {
+				write(string(c))
+			} else if started {
+				space.WriteByte(c)
+			}
+			i++
+		case c == '"':
+			inQuote = !inQuote
+			write("")
+			i++
+		case c == '\\':
+			if i+1 >= len(src) {
+				return "", 0, errors.New("trailing backslash")
+			}
+			i += 2
+			switch src[i-1] {
+			case '\n':
+				*line++
+			case '\r':
+				if i < len(src) && src[i] == '\n' {
+					i++
+					*line++
+				}
+			case 'n':
+				write("\n")
+			case 't':
+				write("\t")
+			case 'b':
+				write("\b")
+			case '\\', '"':
+				write(string(src[i-1]))
+			default:
+				return "", 0, fmt.Errorf("invalid escape \\%c", src[i-1])
+			}
+		default:
+			write(string(c))
+			i++
+		}
+	}
+	if inQuote {
+		return "", 0, errors.New("unterminated quote")
+	}
+	return value.String(), i, nil
+}
@@ -0,0 +1,315 @@
+package gitopen
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_parseGitConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		want    []configEntry
+		wantErr bool
+	}{
+		{
+			name: "sections and subsections",
+			src:  "[core]\n\tbare = false\n[remote \"origin\"]\n\turl = git@github.com:owner/repo.git\n",
+			want: []configEntry{
+				{section: "core", key: "bare", value: "false"},
+				{section: "remote", subsection: "origin", key: "url", value: "git@github.com:owner/repo.git"},
+			},
+		},
+		{
+			name: "case-insensitive names, case-sensitive subsection",
+			src:  "[Remote \"Upstream\"]\nURL=x",
+			want: []configEntry{{section: "remote", subsection: "Upstream", key: "url", value: "x"}},
+		},
+		{
+			name: "deprecated subsection syntax",
+			src:  "[branch.Main]\nremote = origin",
+			want: []configEntry{{section: "branch", subsection: "main", key: "remote", value: "origin"}},
+		},
+		{
+			name: "comments and whitespace",
+			src:  "# comment\n; comment\n[user] # trailing\n  name =  Jane   Doe  ; comment\n",
+			want: []configEntry{{section: "user", key: "name", value: "Jane   Doe"}},
+		},
+		{
+			name: "quotes and escapes",
+			src:  "[alias]\n\tx = \"a ; b\" \\\"c\\\" d\\te\\\\\n",
+			want: []configEntry{{section: "alias", key: "x", value: "a ; b \"c\" d\te\\"}},
+		},
+		{
+			name: "line continuation",
+			src:  "[alias]\n\tx = one \\\ntwo\n",
+			want: []configEntry{{section: "alias", key: "x", value: "one two"}},
+		},
+		{
+			name: "escaped subsection",
+			src:  "[remote \"a\\\"b\"]\nurl = x",
+			want: []configEntry{{section: "remote", subsection: "a\"b", key: "url", value: "x"}},
+		},
+		{
+			name: "key without value",
+			src:  "[core]\n\tbare\n",
+			want: []configEntry{{section: "core", key: "bare", value: "true"}},
+		},
+		{
+			name: "CRLF line endings",
+			src:  "[core]\r\n\tbare = true\r\n",
+			want: []configEntry{{section: "core", key: "bare", value: "true"}},
+		},
+		{name: "key outside section", src: "url = x", wantErr: true},
+		{name: "unterminated quote", src: "[a]\nb = \"c\n", wantErr: true},
+		{name: "invalid escape", src: "[a]\nb = \\q", wantErr: true},
+		{name: "bad section header", src: "[a b]\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseGitConfig(tt.src)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseGitConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseGitConfig() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_gitdirPattern(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		pattern string
+		from    string
+		want    string
+	}{
+		{"/src/work/", "/home/me/.gitconfig", "/src/work/**"},
+		{"~/work/", "/home/me/.gitconfig", filepath.ToSlash(home) + "/work/**"},
+		{"./work/", "/etc/gitconfig.d/main", "/etc/gitconfig.d/work/**"},
+		{"work/.git", "/home/me/.gitconfig", "**/work/.git"},
+	}
+
+	for _, tt := range tests {
+		if got := gitdirPattern(tt.pattern, tt.from); got != tt.want {
+			t.Errorf("gitdirPattern(%q, %q) = %q, want %q", tt.pattern, tt.from, got, tt.want)
+		}
+	}
+}
+
+func Test_gitdirMatches(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		gitDir   string
+		foldCase bool
+		want     bool
+	}{
+		{"/src/work/**", "/src/work/app/.git", false, true},
+		{"/src/work/**", "/src/personal/app/.git", false, false},
+		{"**/app/.git", "/src/work/app/.git", false, true},
+		{"/src/*/app/.git", "/src/work/app/.git", false, true},
+		{"/src/*/.git", "/src/work/app/.git", false, false},
+		{"/src/[wp]ork/**", "/src/work/app/.git", false, true},
+		{"/src/WORK/**", "/src/work/app/.git", false, false},
+		{"/src/WORK/**", "/src/work/app/.git", true, true},
+	}
+
+	for _, tt := range tests {
+		if got := gitdirMatches(tt.pattern, tt.gitDir, tt.foldCase); got != tt.want {
+			t.Errorf("gitdirMatches(%q, %q, %v) = %v, want %v", tt.pattern, tt.gitDir, tt.foldCase, got, tt.want)
+		}
+	}
+}
+
+func TestConfig_readFile_Includes(t *testing.T) {
+	dir := t.TempDir()
+	gitDir := filepath.Join(dir, "work", "app", ".git")
+	if err := os.MkdirAll(gitDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	write := func(name, content string) string {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	write("common.inc", "[user]\n\tname = Common\n")
+	write("work.inc", "[user]\n\temail = me@work.example\n")
+	write("personal.inc", "[user]\n\temail = me@home.example\n")
+	config := write("config", `[user]
+	email = default@example.com
+[include]
+	path = common.inc
+	path = missing.inc
+[includeIf "gitdir:`+filepath.ToSlash(dir)+`/work/"]
+	path = work.inc
+[includeIf "gitdir:`+filepath.ToSlash(dir)+`/personal/"]
+	path = personal.inc
+`)
+
+	cfg := &Config{}
+	if err := cfg.readFile(config, &configIncludeContext{gitDir: gitDir}, 0); err != nil {
+		t.Fatalf("readFile() error = %v", err)
+	}
+	if got, _ := cfg.Get("user", "", "name"); got != "Common" {
+		t.Errorf("user.name = %q, want Common", got)
+	}
+	if got, _ := cfg.Get("user", "", "email"); got != "me@work.example" {
+		t.Errorf("user.email = %q, want me@work.example", got)
+	}
+	if got := cfg.GetAll("user", "", "email"); len(got) != 2 {
+		t.Errorf("GetAll(user.email) = %q, want the default and the work address", got)
+	}
+
+	t.Run("include loop", func(t *testing.T) {
+		loop := write("loop", "[include]\n\tpath = loop\n")
+		err := (&Config{}).readFile(loop, nil, 0)
+		if err == nil || !strings.Contains(err.Error(), "include depth") {
+			t.Errorf("readFile() error = %v, want include depth error", err)
+		}
+	})
+}
+
+// TestMain keeps the system and user git config from affecting the tests.
+func TestMain(m *testing.M) {
+	os.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	os.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	os.Exit(m.Run())
+}
+
+func Test_globalConfigPaths(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	t.Run("GIT_CONFIG_GLOBAL", func(t *testing.T) {
+		t.Setenv("GIT_CONFIG_GLOBAL", "/etc/custom")
+		if got := globalConfigPaths(); !reflect.DeepEqual(got, []string{"/etc/custom"}) {
+			t.Errorf("globalConfigPaths() = %q, want [/etc/custom]", got)
+		}
+	})
+
+	t.Run("XDG_CONFIG_HOME", func(t *testing.T) {
+		os.Unsetenv("GIT_CONFIG_GLOBAL")
+		t.Cleanup(func() { os.Setenv("GIT_CONFIG_GLOBAL", os.DevNull) })
+		t.Setenv("XDG_CONFIG_HOME", "/xdg")
+
+		want := []string{filepath.Join("/xdg", "git", "config"), filepath.Join(home, ".gitconfig")}
+		if got := globalConfigPaths(); !reflect.DeepEqual(got, want) {
+			t.Errorf("globalConfigPaths() = %q, want %q", got, want)
+		}
+
+		t.Setenv("XDG_CONFIG_HOME", "")
+		want[0] = filepath.Join(home, ".config", "git", "config")
+		if got := globalConfigPaths(); !reflect.DeepEqual(got, want) {
+			t.Errorf("globalConfigPaths() = %q, want %q", got, want)
+		}
+	})
+}
+
+func TestConfig_RewriteURL(t *testing.T) {
+	cfg := &Config{entries: []configEntry{
+		{section: "url", subsection: "git@github.com:", key: "insteadof", value: "https://github.com/"},
+		{section: "url", subsection: "git@github.com:work/", key: "insteadof", value: "https://github.com/work/"},
+		{section: "url", subsection: "https://github.com/", key: "insteadof", value: "gh:"},
+		{section: "url", subsection: "ssh://push.example.com/", key: "pushinsteadof", value: "https://example.com/"},
+	}}
+
+	tests := []struct {
+		rawURL string
+		want   string
+	}{
+		{"https://github.com/owner/repo.git", "git@github.com:owner/repo.git"},
+		{"https://github.com/work/repo.git", "git@github.com:work/repo.git"},
+		{"gh:owner/repo", "https://github.com/owner/repo"},
+		{"https://example.com/repo.git", "https://example.com/repo.git"},
+	}
+
+	for _, tt := range tests {
+		if got := cfg.RewriteURL(tt.rawURL); got != tt.want {
+			t.Errorf("RewriteURL(%q) = %q, want %q", tt.rawURL, got, tt.want)
+		}
+	}
+}
+
+func TestReadConfig(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	gitDir := filepath.Join(dir, "repo", ".git")
+	write("repo/.git/HEAD", "ref: refs/heads/release/1.0\n")
+	write("repo/.git/config", `[core]
+	repositoryformatversion = 1
+[extensions]
+	worktreeConfig = true
+[remote "origin"]
+	url = https://github.com/work/repo.git
+[user]
+	name = Repository
+`)
+	write("repo/.git/config.worktree", "[user]\n\temail = worktree@example.com\n")
+	write("release.inc", "[user]\n\tsigningkey = RELEASE\n")
+	write("work.inc", "[url \"git@github.com:\"]\n\tinsteadOf = https://github.com/\n")
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "")
+	t.Setenv("GIT_CONFIG_SYSTEM", write("system", "[user]\n\tname = System\n\temail = system@example.com\n"))
+	t.Setenv("GIT_CONFIG_GLOBAL", write("global", `[user]
+	name = Global
+[includeIf "onbranch:release/"]
+	path = release.inc
+[includeIf "hasconfig:remote.*.url:https://github.com/work/**"]
+	path = work.inc
+`))
+
+	cfg, err := ReadConfig(gitDir, gitDir, nil)
+	if err != nil {
+		t.Fatalf("ReadConfig() error = %v", err)
+	}
+
+	tests := []struct {
+		section, key string
+		want         string
+	}{
+		{"user", "name", "Repository"},
+		{"user", "email", "worktree@example.com"},
+		{"user", "signingkey", "RELEASE"},
+	}
+	for _, tt := range tests {
+		if got, _ := cfg.Get(tt.section, "", tt.key); got != tt.want {
+			t.Errorf("%s.%s = %q, want %q", tt.section, tt.key, got, tt.want)
+		}
+	}
+	if got := cfg.GetAll("user", "", "name"); !reflect.DeepEqual(got, []string{"System", "Global", "Repository"}) {
+		t.Errorf("user.name layers = %q, want system, global then repository", got)
+	}
+	if got := cfg.RewriteURL("https://github.com/work/repo.git"); got != "git@github.com:work/repo.git" {
+		t.Errorf("RewriteURL() = %q, want the hasconfig-included insteadOf applied", got)
+	}
+
+	t.Run("missing repository config", func(t *testing.T) {
+		if _, err := ReadConfig(filepath.Join(dir, "missing"), filepath.Join(dir, "missing"), nil); err == nil {
+			t.Error("ReadConfig() expected error without a repository config, got nil")
+		}
+	})
+}
@@ -0,0 +1,266 @@
+package gitopen
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-billy/v5/osfs"
+	"github.com/go-git/go-git/v5"
+)
+
+// Discovery is what git reads from its environment to find a repository,
+// passed in rather than read from the process environment. The zero value
+// searches upwards from the directory given, stopping at filesystem
+// boundaries, like git without any of these variables set.
+type Discovery struct {
+	// GitDir and WorkTree are GIT_DIR and GIT_WORK_TREE: the git directory
+	// and working tree Open opens instead of searching, relative to the
+	// directory given to it. Discover and FindGitDir ignore them.
+	GitDir, WorkTree string
+	// CeilingDirectories are GIT_CEILING_DIRECTORIES, the directories the
+	// search does not enter. Relative ones are ignored, like git does.
+	CeilingDirectories []string
+	// AcrossFilesystems is GIT_DISCOVERY_ACROSS_FILESYSTEM, continuing the
+	// search past the filesystem of the directory it started in.
+	AcrossFilesystems bool
+	// FileSystemID returns an identifier of the filesystem holding path, and
+	// false when it cannot be determined; the device number of path when
+	// nil.
+	FileSystemID func(path string) (uint64, bool)
+}
+
+// DiscoveryFromEnv returns the Discovery of the environment getenv reads, e.g.
+// os.Getenv: GIT_DIR, GIT_WORK_TREE, GIT_CEILING_DIRECTORIES and
+// GIT_DISCOVERY_ACROSS_FILESYSTEM.
+func DiscoveryFromEnv(getenv func(key string) string) Discovery {
+	d := Discovery{
+		GitDir:            getenv("GIT_DIR"),
+		WorkTree:          getenv("GIT_WORK_TREE"),
+		AcrossFilesystems: ParseBool(getenv("GIT_DISCOVERY_ACROSS_FILESYSTEM")),
+	}
+	for _, ceiling := range filepath.SplitList(getenv("GIT_CEILING_DIRECTORIES")) {
+		if ceiling != "" {
+			d.CeilingDirectories = append(d.CeilingDirectories, ceiling)
+		}
+	}
+	return d
+}
+
+// discoveryLimits bounds the upward search for a repository.
+type discoveryLimits struct {
+	// ceilings are the absolute ceiling directories, also with symlinks
+	// resolved.
+	ceilings []string
+	// fileSystemID identifies filesystems, nil when the search may cross
+	// them.
+	fileSystemID func(path string) (uint64, bool)
+	// startID is the filesystem of the directory the search started in.
+	startID   uint64
+	haveStart bool
+}
+
+// limits returns the limits of d for a search starting at the absolute path
+// start.
+func (d Discovery) limits(start string) *discoveryLimits {
+	limits := &discoveryLimits{}
+	for _, ceiling := range d.CeilingDirectories {
+		// git ignores relative entries
+		if !filepath.IsAbs(ceiling) {
+			continue
+		}
+		limits.ceilings = append(limits.ceilings, filepath.Clean(ceiling))
+		if resolved, err := filepath.EvalSymlinks(ceiling); err == nil {
+			limits.ceilings = append(limits.ceilings, resolved)
+		}
+	}
+	if !d.AcrossFilesystems {
+		limits.fileSystemID = d.FileSystemID
+		if limits.fileSystemID == nil {
+			limits.fileSystemID = deviceID
+		}
+		limits.startID, limits.haveStart = limits.fileSystemID(start)
+	}
+	return limits
+}
+
+// check returns ErrNotRepository if the search may not continue into the
+// parent directory dir.
+func (l *discoveryLimits) check(dir string) error {
+	for _, ceiling := range l.ceilings {
+		if dir == ceiling {
+			return fmt.Errorf("%w (stopped at ceiling directory %s)", ErrNotRepository, dir)
+		}
+	}
+	if l.haveStart {
+		if id, ok := l.fileSystemID(dir); ok && id != l.startID {
+			return fmt.Errorf("%w (stopped at filesystem boundary %s; set GIT_DISCOVERY_ACROSS_FILESYSTEM to search further)", ErrNotRepository, dir)
+		}
+	}
+	return nil
+}
+
+// ParseBool reports whether s is one of git's true boolean values: true, yes,
+// on or 1, ignoring case.
+func ParseBool(s string) bool {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "true", "yes", "on", "1":
+		return true
+	}
+	return false
+}
+
+// FindGitDir returns the git directory of the repository containing dir and
+// its working tree, which is "" for a bare repository. Like git, it walks up
+// from dir, following .git files ("gitdir: <path>") of worktrees and
+// submodules, does not enter the ceiling directories of d, and stops at
+// filesystem boundaries unless d allows crossing them. The GitDir and
+// WorkTree of d are not consulted.
+func FindGitDir(dir string, d Discovery, logger *slog.Logger) (gitDir, workTree string, err error) {
+	dot, wt, err := dotGitFilesystems(dir, d, orDiscard(logger))
+	if err != nil {
+		return "", "", err
+	}
+	if wt != nil {
+		workTree = wt.Root()
+	}
+	return dot.Root(), workTree, nil
+}
+
+// dotGitFilesystems is FindGitDir returning filesystems, wt being nil for a
+// bare repository.
+func dotGitFilesystems(path string, d Discovery, logger *slog.Logger) (dot, wt billy.Filesystem, err error) {
+	if path, err = filepath.Abs(path); err != nil {
+		return nil, nil, err
+	}
+
+	limits := d.limits(path)
+	var fs billy.Filesystem
+	var fi os.FileInfo
+	for {
+		logger.Debug("searching for repository", "dir", path)
+		fs = osfs.New(path)
+		fi, err = fs.Stat(git.GitDirName)
+		if err == nil {
+			break
+		}
+		if !os.IsNotExist(err) {
+			return nil, nil, err
+		}
+		if isBareRepository(fs) {
+			return fs, nil, nil
+		}
+		dir := filepath.Dir(path)
+		if dir == path {
+			return nil, nil, ErrNotRepository
+		}
+		if err := limits.check(dir); err != nil {
+			return nil, nil, err
+		}
+		path = dir
+	}
+
+	if fi.IsDir() {
+		dot, err = fs.Chroot(git.GitDirName)
+		return dot, fs, err
+	}
+
+	dot, err = dotGitFileToFilesystem(path, fs)
+	if err != nil {
+		return nil, nil, err
+	}
+	return dot, fs, nil
+}
+
+// IsGitDir reports whether dir is a git directory, recognised like git does by
+// a HEAD file next to objects and refs directories.
+func IsGitDir(dir string) bool {
+	return isBareRepository(osfs.New(dir))
+}
+
+// isBareRepository is IsGitDir for a filesystem.
+func isBareRepository(fs billy.Filesystem) bool {
+	if fi, err := fs.Stat("HEAD"); err != nil || fi.IsDir() {
+		return false
+	}
+	for _, dir := range []string{"objects", "refs"} {
+		if fi, err := fs.Stat(dir); err != nil || !fi.IsDir() {
+			return false
+		}
+	}
+	return true
+}
+
+// dotGitFileToFilesystem resolves a .git file to the git directory it points to.
+func dotGitFileToFilesystem(path string, fs billy.Filesystem) (billy.Filesystem, error) {
+	f, err := fs.Open(git.GitDirName)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	b, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+
+	line := string(b)
+	const prefix = "gitdir: "
+	if !strings.HasPrefix(line, prefix) {
+		return nil, fmt.Errorf(".git file has no %s prefix", prefix)
+	}
+
+	gitdir := strings.TrimSpace(strings.Split(line[len(prefix):], "\n")[0])
+	if filepath.IsAbs(gitdir) {
+		return osfs.New(gitdir), nil
+	}
+	return osfs.New(fs.Join(path, gitdir)), nil
+}
+
+// CommonGitDir returns the git directory shared by all worktrees of the
+// repository whose git directory is gitDir, holding its config and refs. It is
+// gitDir itself unless gitDir belongs to a linked worktree.
+func CommonGitDir(gitDir string) (string, error) {
+	b, err := os.ReadFile(filepath.Join(gitDir, "commondir"))
+	if os.IsNotExist(err) {
+		return gitDir, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	common := strings.TrimSpace(string(b))
+	if !filepath.IsAbs(common) {
+		common = filepath.Join(gitDir, common)
+	}
+	return filepath.Clean(common), nil
+}
+
+// HeadBranch returns the short name of the branch HEAD in gitDir points to, or
+// "" for a detached HEAD.
+func HeadBranch(gitDir string) string {
+	b, err := os.ReadFile(filepath.Join(gitDir, "HEAD"))
+	if err != nil {
+		return ""
+	}
+	ref, ok := strings.CutPrefix(strings.TrimSpace(string(b)), "ref: ")
+	if !ok {
+		return ""
+	}
+	branch, _ := strings.CutPrefix(ref, "refs/heads/")
+	if branch == ref {
+		return ""
+	}
+	return branch
+}
+
+// orDiscard returns logger, or a logger discarding everything when it is nil.
+func orDiscard(logger *slog.Logger) *slog.Logger {
+	if logger == nil {
+		return slog.New(slog.DiscardHandler)
+	}
+	return logger
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package gitopen
+
+// deviceID is not implemented on this platform, so discovery never stops at
+// filesystem boundaries.
+func deviceID(path string) (uint64, bool) {
+	return 0, false
+}
@@ -0,0 +1,182 @@
+package gitopen
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-billy/v5/osfs"
+	"github.com/go-git/go-git/v5"
+)
+
+func TestParseBool(t *testing.T) {
+	for value, want := range map[string]bool{
+		"":      false,
+		"1":     true,
+		"true":  true,
+		"Yes":   true,
+		"on":    true,
+		"0":     false,
+		"false": false,
+		"no":    false,
+	} {
+		if got := ParseBool(value); got != want {
+			t.Errorf("ParseBool(%q) = %v, want %v", value, got, want)
+		}
+	}
+}
+
+func TestDiscover_CeilingDirectories(t *testing.T) {
+	root := t.TempDir()
+	repoDir := filepath.Join(root, "repo")
+	initRepository(t, repoDir)
+	start := filepath.Join(repoDir, "a", "b")
+	if err := os.MkdirAll(start, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		ceilings string
+		wantErr  bool
+	}{
+		{"no ceiling", "", false},
+		{"ceiling above the repository", root, false},
+		{"ceiling inside the repository", filepath.Join(repoDir, "a"), true},
+		{"ceiling list", "relative" + string(os.PathListSeparator) + filepath.Join(repoDir, "a"), true},
+		{"ceiling is the start directory", start, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := DiscoveryFromEnv(func(key string) string {
+				if key == "GIT_CEILING_DIRECTORIES" {
+					return tt.ceilings
+				}
+				return ""
+			})
+			_, err := Discover(start, d, nil)
+			if tt.wantErr {
+				if !errors.Is(err, ErrNotRepository) || !strings.Contains(err.Error(), "ceiling") {
+					t.Errorf("Discover() error = %v, want stop at ceiling", err)
+				}
+			} else if err != nil {
+				t.Errorf("Discover() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestDiscover_FilesystemBoundary(t *testing.T) {
+	root := t.TempDir()
+	initRepository(t, root)
+	mount := filepath.Join(root, "mnt", "disk")
+	if err := os.MkdirAll(mount, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// Pretend everything below mnt is another filesystem
+	d := Discovery{FileSystemID: func(path string) (uint64, bool) {
+		if strings.HasPrefix(path, filepath.Join(root, "mnt")+string(filepath.Separator)) {
+			return 2, true
+		}
+		return 1, true
+	}}
+
+	if _, err := Discover(mount, d, nil); !errors.Is(err, ErrNotRepository) || !strings.Contains(err.Error(), "filesystem boundary") {
+		t.Errorf("Discover() error = %v, want stop at filesystem boundary", err)
+	}
+
+	d.AcrossFilesystems = true
+	if _, err := Discover(mount, d, nil); err != nil {
+		t.Errorf("Discover() across filesystems error = %v", err)
+	}
+}
+
+func TestDiscoveryFromEnv(t *testing.T) {
+	env := map[string]string{
+		"GIT_DIR":                         ".dotfiles",
+		"GIT_WORK_TREE":                   "/home/user",
+		"GIT_CEILING_DIRECTORIES":         "/home" + string(os.PathListSeparator) + string(os.PathListSeparator) + "/srv",
+		"GIT_DISCOVERY_ACROSS_FILESYSTEM": "yes",
+	}
+	got := DiscoveryFromEnv(func(key string) string { return env[key] })
+	if got.GitDir != ".dotfiles" || got.WorkTree != "/home/user" || !got.AcrossFilesystems || strings.Join(got.CeilingDirectories, ",") != "/home,/srv" {
+		t.Errorf("DiscoveryFromEnv() = %+v, want the variables read", got)
+	}
+}
+
+// initRepository creates an empty repository at dir.
+func initRepository(t *testing.T, dir string) {
+	t.Helper()
+	if _, err := git.PlainInit(dir, false); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDotGitFileToFilesystem(t *testing.T) {
+	absGitdir := t.TempDir()
+
+	tests := []struct {
+		name     string
+		content  string
+		wantRoot string
+		wantErr  bool
+	}{
+		{
+			name:     "relative gitdir",
+			content:  "gitdir: .git-real\n",
+			wantRoot: ".git-real",
+		},
+		{
+			name:     "absolute gitdir",
+			content:  "gitdir: " + absGitdir + "\n",
+			wantRoot: absGitdir,
+		},
+		{
+			name:    "missing gitdir prefix",
+			content: "not a gitdir pointer\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(tmpDir, ".git"), []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			fs, err := dotGitFileToFilesystem(tmpDir, osfs.New(tmpDir))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("dotGitFileToFilesystem() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			want := tt.wantRoot
+			if !filepath.IsAbs(want) {
+				want = filepath.Join(tmpDir, want)
+			}
+			// osfs.Root resolves symlinks (e.g. /var -> /private/var on macOS).
+			if resolved, err := filepath.EvalSymlinks(want); err == nil {
+				want = resolved
+			}
+			if fs.Root() != want {
+				t.Errorf("dotGitFileToFilesystem() root = %q, want %q", fs.Root(), want)
+			}
+		})
+	}
+}
+
+func TestDotGitFileToFilesystem_MissingFile(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	_, err := dotGitFileToFilesystem(tmpDir, osfs.New(tmpDir))
+	if err == nil {
+		t.Error("dotGitFileToFilesystem() expected error for missing .git file, got nil")
+	}
+}
@@ -0,0 +1,14 @@
+//go:build unix
+
+package gitopen
+
+import "syscall"
+
+// deviceID returns the device number of the filesystem holding path.
+func deviceID(path string) (uint64, bool) {
+	var st syscall.Stat_t
+	if err := syscall.Stat(path, &st); err != nil {
+		return 0, false
+	}
+	return uint64(st.Dev), true
+}
@@ -0,0 +1,20 @@
+// Package gitopen resolves the web page of a Git repository: the home page, or
+// the page of the branch, tag or commit checked out, on the hosting service
+// its remote points to.
+//
+// It is the library behind the git-open command, for programs that would
+// otherwise run it and parse its output. Like the command, it reads the
+// effective git config of the repository, including its git-open.* keys.
+// Unlike the command, it keeps no global state and reads no YAML config:
+// everything git-open reads from ~/.git-open.yaml is passed in Options, and
+// so is the environment git finds repositories with.
+//
+//	target, err := gitopen.Resolve(ctx, ".", gitopen.Options{
+//		Remote:    "upstream",
+//		Discovery: gitopen.DiscoveryFromEnv(os.Getenv),
+//	})
+//	if errors.Is(err, gitopen.ErrNotRepository) {
+//		return nil
+//	}
+//	fmt.Println(target.WebURL)
+package gitopen
@@ -0,0 +1,25 @@
+package gitopen
+
+import (
+	"errors"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+var (
+	// ErrNotRepository is returned outside a Git repository. It is go-git's
+	// error, so it also matches the errors of go-git itself.
+	ErrNotRepository = git.ErrRepositoryNotExists
+	// ErrNoRemote is returned when the remote to open does not exist or has no
+	// URL.
+	ErrNoRemote = git.ErrRemoteNotFound
+	// ErrUnsupportedURL is returned for remote URLs without a known web URL.
+	ErrUnsupportedURL = errors.New("unsupported remote URL format")
+	// ErrRefNotFound is returned when a branch, tag or commit, such as the
+	// one HEAD points to, does not exist.
+	ErrRefNotFound = plumbing.ErrReferenceNotFound
+	// ErrUnknownProvider is returned for provider names missing from the
+	// registry.
+	ErrUnknownProvider = errors.New("unknown provider")
+)
@@ -0,0 +1,263 @@
+package gitopen
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-billy/v5/osfs"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing/cache"
+	"github.com/go-git/go-git/v5/storage"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+	"github.com/go-git/go-git/v5/storage/filesystem/dotgit"
+)
+
+// Open opens the repository git would in dir: the one the GitDir and
+// WorkTree of d select when either is set, as GIT_DIR and GIT_WORK_TREE do for
+// bare-repository dotfile setups, or else the one Discover finds. Relative
+// GitDir and WorkTree paths are relative to dir.
+func Open(dir string, d Discovery, logger *slog.Logger) (*git.Repository, error) {
+	if d.GitDir == "" && d.WorkTree == "" {
+		return Discover(dir, d, logger)
+	}
+	return openFromEnvironment(dir, d, orDiscard(logger))
+}
+
+// Discover opens the repository containing dir, found like FindGitDir finds
+// it. Like git, and unlike go-git, it opens repositories enabling config
+// extensions go-git does not implement (e.g. extensions.worktreeConfig),
+// which reading the remotes and HEAD does not need.
+func Discover(dir string, d Discovery, logger *slog.Logger) (*git.Repository, error) {
+	logger = orDiscard(logger)
+	dot, wt, err := dotGitFilesystems(dir, d, logger)
+	if err != nil {
+		return nil, err
+	}
+	return openFilesystems(dot, wt, logger)
+}
+
+// OpenWorkTree opens the repository whose git directory is gitDir and whose
+// working tree is workTree, "" for a bare repository, as FindGitDir returns
+// them, without searching for it again.
+func OpenWorkTree(gitDir, workTree string, logger *slog.Logger) (*git.Repository, error) {
+	var wt billy.Filesystem
+	if workTree != "" {
+		wt = osfs.New(workTree)
+	}
+	return openFilesystems(osfs.New(gitDir), wt, orDiscard(logger))
+}
+
+// openFilesystems opens the repository in the git directory dot with the
+// working tree wt, nil for a bare repository, tolerating extensions go-git
+// does not implement.
+func openFilesystems(dot, wt billy.Filesystem, logger *slog.Logger) (*git.Repository, error) {
+	// A bare repository is opened from its git directory
+	root := dot.Root()
+	if wt != nil {
+		root = wt.Root()
+	}
+
+	repo, err := git.PlainOpenWithOptions(root, &git.PlainOpenOptions{
+		EnableDotGitCommonDir: true,
+	})
+	if err == nil {
+		return repo, nil
+	}
+	if !isRepositoryFormatError(err) {
+		return nil, err
+	}
+	logger.Debug("go-git rejected the repository format, ignoring [extensions]", "error", err)
+
+	s, err := dotGitStorage(dot)
+	if err != nil {
+		return nil, err
+	}
+	return git.Open(s, wt)
+}
+
+// OpenGitDir opens the repository whose git directory is gitDir, with the
+// working tree its core.worktree names, or the directory holding gitDir when
+// that is a .git directory. Other repositories are opened bare.
+func OpenGitDir(gitDir string) (*git.Repository, error) {
+	s, err := dotGitStorage(osfs.New(gitDir))
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := s.Config()
+	if err != nil {
+		return nil, err
+	}
+
+	var wt billy.Filesystem
+	switch worktree := cfg.Core.Worktree; {
+	case worktree != "":
+		if !filepath.IsAbs(worktree) {
+			worktree = filepath.Join(gitDir, worktree)
+		}
+		wt = osfs.New(worktree)
+	case filepath.Base(gitDir) == git.GitDirName:
+		wt = osfs.New(filepath.Dir(gitDir))
+	}
+	return git.Open(s, wt)
+}
+
+// openFromEnvironment opens the repository selected by the GitDir and
+// WorkTree of d from dir. Without GitDir the git directory is discovered from
+// dir; without WorkTree the worktree is core.worktree, or dir unless the
+// repository is bare.
+func openFromEnvironment(dir string, d Discovery, logger *slog.Logger) (*git.Repository, error) {
+	gitDir, workTree := d.GitDir, d.WorkTree
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	logger.Debug("opening repository from the environment", "git_dir", gitDir, "work_tree", workTree)
+
+	var dot, wt billy.Filesystem
+	if gitDir != "" {
+		dot = osfs.New(resolvePath(dir, gitDir))
+	} else if dot, wt, err = dotGitFilesystems(dir, d, logger); err != nil {
+		return nil, err
+	}
+
+	s, err := dotGitStorage(dot)
+	if err != nil {
+		return nil, fmt.Errorf("GIT_DIR %s: %w", dot.Root(), err)
+	}
+
+	switch {
+	case workTree != "":
+		wt = osfs.New(resolvePath(dir, workTree))
+	case gitDir != "":
+		cfg, err := s.Config()
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case cfg.Core.Worktree != "":
+			wt = osfs.New(resolvePath(dot.Root(), cfg.Core.Worktree))
+		case !cfg.Core.IsBare:
+			wt = osfs.New(dir)
+		}
+	}
+
+	return git.Open(s, wt)
+}
+
+// resolvePath returns path, or path relative to dir when it is relative.
+func resolvePath(dir, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+// isRepositoryFormatError reports whether err is one of go-git's strict
+// repository format/extension validation errors.
+func isRepositoryFormatError(err error) bool {
+	return errors.Is(err, git.ErrUnsupportedExtensionRepositoryFormatVersion) ||
+		errors.Is(err, git.ErrUnknownExtension) ||
+		errors.Is(err, git.ErrUnsupportedRepositoryFormatVersion)
+}
+
+// extensionTolerantStorer wraps a storage.Storer and hides the [extensions]
+// section of the repository config, so go-git's extension validation passes
+// for extensions it does not implement.
+type extensionTolerantStorer struct {
+	storage.Storer
+}
+
+func (s extensionTolerantStorer) Config() (*config.Config, error) {
+	cfg, err := s.Storer.Config()
+	if err != nil {
+		return nil, err
+	}
+	if cfg != nil && cfg.Raw != nil {
+		cfg.Raw.RemoveSection("extensions")
+	}
+	return cfg, nil
+}
+
+// IgnoresExtensions reports whether repo was opened hiding the [extensions]
+// section of its config, which go-git would have rejected.
+func IgnoresExtensions(repo *git.Repository) bool {
+	_, ok := repo.Storer.(extensionTolerantStorer)
+	return ok
+}
+
+// GitDir returns the git directory repo is stored in, or "" when it is not
+// stored on disk.
+func GitDir(repo *git.Repository) string {
+	s := repo.Storer
+	if tolerant, ok := s.(extensionTolerantStorer); ok {
+		s = tolerant.Storer
+	}
+	if fs, ok := s.(interface{ Filesystem() billy.Filesystem }); ok {
+		return fs.Filesystem().Root()
+	}
+	return ""
+}
+
+// dotGitStorage returns the storage for the git directory dot, following its
+// commondir file and hiding the [extensions] config section.
+func dotGitStorage(dot billy.Filesystem) (storage.Storer, error) {
+	if _, err := dot.Stat(""); err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNotRepository
+		}
+		return nil, err
+	}
+
+	repositoryFs := dot
+	if common, err := dotGitCommonDirectory(dot); err != nil {
+		return nil, err
+	} else if common != nil {
+		repositoryFs = dotgit.NewRepositoryFilesystem(dot, common)
+	}
+
+	s := filesystem.NewStorage(repositoryFs, cache.NewObjectLRUDefault())
+	return extensionTolerantStorer{Storer: s}, nil
+}
+
+// dotGitCommonDirectory returns the common git directory referenced by a
+// "commondir" file, or nil when the file does not exist.
+func dotGitCommonDirectory(fs billy.Filesystem) (billy.Filesystem, error) {
+	f, err := fs.Open("commondir")
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	b, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return nil, nil
+	}
+
+	common := strings.TrimSpace(string(b))
+	var commonDir billy.Filesystem
+	if filepath.IsAbs(common) {
+		commonDir = osfs.New(common)
+	} else {
+		commonDir = osfs.New(filepath.Join(fs.Root(), common))
+	}
+	if _, err := commonDir.Stat(""); err != nil {
+		if os.IsNotExist(err) {
+			return nil, git.ErrRepositoryIncomplete
+		}
+		return nil, err
+	}
+	return commonDir, nil
+}
@@ -0,0 +1,95 @@
+package gitopen
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-billy/v5/osfs"
+	"github.com/go-git/go-git/v5"
+)
+
+func TestDotGitCommonDirectory(t *testing.T) {
+	t.Run("no commondir file", func(t *testing.T) {
+		fs := osfs.New(t.TempDir())
+		common, err := dotGitCommonDirectory(fs)
+		if err != nil {
+			t.Fatalf("dotGitCommonDirectory() error = %v", err)
+		}
+		if common != nil {
+			t.Errorf("dotGitCommonDirectory() = %v, want nil", common)
+		}
+	})
+
+	t.Run("empty commondir file", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(tmpDir, "commondir"), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+		common, err := dotGitCommonDirectory(osfs.New(tmpDir))
+		if err != nil {
+			t.Fatalf("dotGitCommonDirectory() error = %v", err)
+		}
+		if common != nil {
+			t.Errorf("dotGitCommonDirectory() = %v, want nil", common)
+		}
+	})
+
+	t.Run("relative commondir", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		gitdir := filepath.Join(tmpDir, "gitdir")
+		commonDir := filepath.Join(tmpDir, "common")
+		for _, dir := range []string{gitdir, commonDir} {
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := os.WriteFile(filepath.Join(gitdir, "commondir"), []byte("../common\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		common, err := dotGitCommonDirectory(osfs.New(gitdir))
+		if err != nil {
+			t.Fatalf("dotGitCommonDirectory() error = %v", err)
+		}
+		// osfs.Root resolves symlinks (e.g. /var -> /private/var on macOS).
+		wantDir, err := filepath.EvalSymlinks(commonDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if common == nil || common.Root() != wantDir {
+			t.Errorf("dotGitCommonDirectory() root = %v, want %q", common, wantDir)
+		}
+	})
+
+	t.Run("absolute commondir", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		commonDir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(tmpDir, "commondir"), []byte(commonDir+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		common, err := dotGitCommonDirectory(osfs.New(tmpDir))
+		if err != nil {
+			t.Fatalf("dotGitCommonDirectory() error = %v", err)
+		}
+		// osfs.Root resolves symlinks (e.g. /var -> /private/var on macOS).
+		wantDir, err := filepath.EvalSymlinks(commonDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if common == nil || common.Root() != wantDir {
+			t.Errorf("dotGitCommonDirectory() root = %v, want %q", common, wantDir)
+		}
+	})
+
+	t.Run("commondir does not exist", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(tmpDir, "commondir"), []byte("missing\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := dotGitCommonDirectory(osfs.New(tmpDir))
+		if !errors.Is(err, git.ErrRepositoryIncomplete) {
+			t.Errorf("dotGitCommonDirectory() error = %v, want %v", err, git.ErrRepositoryIncomplete)
+		}
+	})
+}
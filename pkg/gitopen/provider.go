@@ -0,0 +1,175 @@
+package gitopen
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Provider is a hosting service: how its pages are laid out below the web URL
+// of a repository.
+type Provider struct {
+	// Name identifies the provider, e.g. "github".
+	Name string
+	// Hosts are the hosts the provider is detected from. Self-hosted
+	// instances are registered with their own hosts, or selected by name
+	// with Options.Provider.
+	Hosts []string
+	// BranchPath is the path of a branch (or commit) page, with %s standing
+	// for the escaped ref, e.g. "/tree/%s".
+	BranchPath string
+	// TagPath is the path of a tag page, with %s standing for the escaped tag.
+	TagPath string
+	// FilePath is the path of a file page, with %s standing for the escaped
+	// ref and file path, e.g. "/blob/%s". Directories use BranchPath.
+	FilePath string
+	// LineAnchor selects a line of a file page, with %d standing for the
+	// line, e.g. "#L%d".
+	LineAnchor string
+	// PullRequestsPath, CIPath, ReleasesPath and IssuesPath are the paths
+	// of the pull (or merge) requests, CI, releases and issues pages, empty
+	// when the provider has none.
+	PullRequestsPath string
+	CIPath           string
+	ReleasesPath     string
+	IssuesPath       string
+}
+
+// BranchURL returns the URL of the page of branch, or of a commit, in the
+// repository at webURL.
+func (p *Provider) BranchURL(webURL, branch string) string {
+	return webURL + fmt.Sprintf(p.BranchPath, EscapePath(branch))
+}
+
+// TagURL returns the URL of the page of tag in the repository at webURL.
+func (p *Provider) TagURL(webURL, tag string) string {
+	return webURL + fmt.Sprintf(p.TagPath, EscapePath(tag))
+}
+
+// FileURL returns the URL of the page of the file, or directory, at path at
+// ref in the repository at webURL, selecting line when it is not 0. The root
+// directory's path is "".
+func (p *Provider) FileURL(webURL, ref, path string, isDir bool, line int) string {
+	format := p.FilePath
+	if isDir {
+		format = p.BranchPath
+	}
+	if path != "" {
+		ref += "/" + path
+	}
+	fileURL := webURL + fmt.Sprintf(format, EscapePath(ref))
+	if line > 0 && !isDir && p.LineAnchor != "" {
+		fileURL += fmt.Sprintf(p.LineAnchor, line)
+	}
+	return fileURL
+}
+
+// PageURL returns the URL of the page at path, one of the provider's page
+// paths, in the repository at webURL, or false when the provider has no such
+// page.
+func (p *Provider) PageURL(webURL, path string) (string, bool) {
+	if path == "" {
+		return "", false
+	}
+	return webURL + path, true
+}
+
+// IssueURL returns the URL of issue in the repository at webURL, or of its
+// issue list when issue is empty, or false when the provider has no issues.
+func (p *Provider) IssueURL(webURL, issue string) (string, bool) {
+	issues, ok := p.PageURL(webURL, p.IssuesPath)
+	if ok && issue != "" {
+		issues += "/" + issue
+	}
+	return issues, ok
+}
+
+// DefaultProviders returns the providers git-open knows: GitHub, GitLab and
+// Bitbucket. Each call returns new values, which callers may change.
+func DefaultProviders() []*Provider {
+	return []*Provider{
+		{
+			Name: "github", Hosts: []string{"github.com"},
+			BranchPath: "/tree/%s", TagPath: "/releases/tag/%s", FilePath: "/blob/%s", LineAnchor: "#L%d",
+			PullRequestsPath: "/pulls", CIPath: "/actions", ReleasesPath: "/releases", IssuesPath: "/issues",
+		},
+		{
+			Name: "gitlab", Hosts: []string{"gitlab.com"},
+			BranchPath: "/-/tree/%s", TagPath: "/-/tags/%s", FilePath: "/-/blob/%s", LineAnchor: "#L%d",
+			PullRequestsPath: "/-/merge_requests", CIPath: "/-/pipelines", ReleasesPath: "/-/releases", IssuesPath: "/-/issues",
+		},
+		{
+			Name: "bitbucket", Hosts: []string{"bitbucket.org"},
+			BranchPath: "/src/%s", TagPath: "/src/%s", FilePath: "/src/%s", LineAnchor: "#lines-%d",
+			PullRequestsPath: "/pull-requests", CIPath: "/pipelines", ReleasesPath: "/downloads", IssuesPath: "/issues",
+		},
+	}
+}
+
+// UnknownProvider returns the provider named "unknown" that lays out the
+// branch, tag and file pages of repositories on unknown hosts like GitHub
+// does, and knows none of their other pages.
+func UnknownProvider() *Provider {
+	return &Provider{Name: "unknown", BranchPath: "/tree/%s", TagPath: "/tree/%s", FilePath: "/blob/%s", LineAnchor: "#L%d"}
+}
+
+// Registry is a set of providers to detect hosting services from.
+type Registry struct {
+	providers []*Provider
+}
+
+// NewRegistry returns a registry of providers, e.g.
+// NewRegistry(DefaultProviders()...).
+func NewRegistry(providers ...*Provider) *Registry {
+	r := &Registry{}
+	for _, p := range providers {
+		r.Register(p)
+	}
+	return r
+}
+
+// Register adds p to the registry, replacing the provider of the same name.
+func (r *Registry) Register(p *Provider) {
+	for i, existing := range r.providers {
+		if strings.EqualFold(existing.Name, p.Name) {
+			r.providers[i] = p
+			return
+		}
+	}
+	r.providers = append(r.providers, p)
+}
+
+// Lookup returns the provider named name, ignoring case.
+func (r *Registry) Lookup(name string) (*Provider, bool) {
+	for _, p := range r.providers {
+		if strings.EqualFold(p.Name, name) {
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+// Detect returns the provider one of whose hosts appears in remoteURL, which
+// may also be a bare host, or UnknownProvider and false. Matching anywhere in
+// the URL detects SSH host aliases such as "git@github.com-work:owner/repo".
+func (r *Registry) Detect(remoteURL string) (*Provider, bool) {
+	remoteURL = strings.ToLower(remoteURL)
+	for _, p := range r.providers {
+		for _, h := range p.Hosts {
+			if strings.Contains(remoteURL, strings.ToLower(h)) {
+				return p, true
+			}
+		}
+	}
+	return UnknownProvider(), false
+}
+
+// EscapePath escapes each segment of a slash-separated path, such as a ref or
+// a file path, for a URL.
+func EscapePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
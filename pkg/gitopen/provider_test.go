@@ -0,0 +1,110 @@
+package gitopen
+
+import "testing"
+
+func TestRegistry(t *testing.T) {
+	registry := NewRegistry(DefaultProviders()...)
+	registry.Register(&Provider{Name: "gitlab", Hosts: []string{"gitlab.com", "gitlab.example.com"}, BranchPath: "/-/tree/%s", TagPath: "/-/tags/%s"})
+	registry.Register(&Provider{Name: "gitea", Hosts: []string{"gitea.example.com"}, BranchPath: "/src/branch/%s", TagPath: "/src/tag/%s"})
+
+	tests := []struct {
+		remoteURL string
+		want      string
+		wantOK    bool
+	}{
+		{"github.com", "github", true},
+		{"GitHub.com", "github", true},
+		{"gitlab.example.com:8443", "gitlab", true},
+		{"gitea.example.com", "gitea", true},
+		{"git@github.com-work:owner/repo.git", "github", true},
+		{"https://bitbucket.org/owner/repo.git", "bitbucket", true},
+		{"git.example.com", "unknown", false},
+	}
+	for _, tt := range tests {
+		if got, ok := registry.Detect(tt.remoteURL); got.Name != tt.want || ok != tt.wantOK {
+			t.Errorf("Detect(%q) = %q, %v, want %q, %v", tt.remoteURL, got.Name, ok, tt.want, tt.wantOK)
+		}
+	}
+
+	if _, ok := registry.Lookup("GitLab"); !ok {
+		t.Error(`Lookup("GitLab") found no provider`)
+	}
+	if _, ok := registry.Lookup("sourcehut"); ok {
+		t.Error(`Lookup("sourcehut") found a provider that was not registered`)
+	}
+	if n := len(registry.providers); n != 4 {
+		t.Errorf("registry has %d providers, want 4 after replacing gitlab", n)
+	}
+	if fresh, _ := NewRegistry(DefaultProviders()...).Lookup("gitlab"); len(fresh.Hosts) != 1 {
+		t.Errorf("registering into one registry changed the default providers: %v", fresh.Hosts)
+	}
+}
+
+func TestProvider_URLs(t *testing.T) {
+	registry := NewRegistry(DefaultProviders()...)
+	tests := []struct {
+		provider   string
+		wantBranch string
+		wantTag    string
+	}{
+		{"github", "https://example.com/o/r/tree/feat/a%23b", "https://example.com/o/r/releases/tag/v1.0"},
+		{"gitlab", "https://example.com/o/r/-/tree/feat/a%23b", "https://example.com/o/r/-/tags/v1.0"},
+		{"bitbucket", "https://example.com/o/r/src/feat/a%23b", "https://example.com/o/r/src/v1.0"},
+	}
+	for _, tt := range tests {
+		p, _ := registry.Lookup(tt.provider)
+		if got := p.BranchURL("https://example.com/o/r", "feat/a#b"); got != tt.wantBranch {
+			t.Errorf("%s BranchURL() = %q, want %q", tt.provider, got, tt.wantBranch)
+		}
+		if got := p.TagURL("https://example.com/o/r", "v1.0"); got != tt.wantTag {
+			t.Errorf("%s TagURL() = %q, want %q", tt.provider, got, tt.wantTag)
+		}
+	}
+}
+
+func TestProvider_FileURL(t *testing.T) {
+	registry := NewRegistry(DefaultProviders()...)
+	tests := []struct {
+		name     string
+		provider string
+		path     string
+		isDir    bool
+		line     int
+		want     string
+	}{
+		{"GitHub file", "github", "cmd/git.go", false, 0, "https://example.com/o/r/blob/main/cmd/git.go"},
+		{"GitHub line", "github", "cmd/git.go", false, 10, "https://example.com/o/r/blob/main/cmd/git.go#L10"},
+		{"GitHub directory", "github", "cmd", true, 0, "https://example.com/o/r/tree/main/cmd"},
+		{"GitLab line", "gitlab", "cmd/git.go", false, 10, "https://example.com/o/r/-/blob/main/cmd/git.go#L10"},
+		{"GitLab directory", "gitlab", "cmd", true, 0, "https://example.com/o/r/-/tree/main/cmd"},
+		{"Bitbucket line", "bitbucket", "cmd/git.go", false, 10, "https://example.com/o/r/src/main/cmd/git.go#lines-10"},
+		{"unknown line", "unknown", "cmd/git.go", false, 10, "https://example.com/o/r/blob/main/cmd/git.go#L10"},
+		{"root directory", "github", "", true, 0, "https://example.com/o/r/tree/main"},
+		{"escaped path", "github", "docs/read me#1.md", false, 0, "https://example.com/o/r/blob/main/docs/read%20me%231.md"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, ok := registry.Lookup(tt.provider)
+			if !ok {
+				p = UnknownProvider()
+			}
+			if got := p.FileURL("https://example.com/o/r", "main", tt.path, tt.isDir, tt.line); got != tt.want {
+				t.Errorf("FileURL() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProvider_IssueURL(t *testing.T) {
+	registry := NewRegistry(DefaultProviders()...)
+	gitlab, _ := registry.Lookup("gitlab")
+	if got, ok := gitlab.IssueURL("https://example.com/o/r", ""); !ok || got != "https://example.com/o/r/-/issues" {
+		t.Errorf("IssueURL() = %q, %v, want the issue list", got, ok)
+	}
+	if got, ok := gitlab.IssueURL("https://example.com/o/r", "42"); !ok || got != "https://example.com/o/r/-/issues/42" {
+		t.Errorf("IssueURL(42) = %q, %v, want issue 42", got, ok)
+	}
+	if got, ok := UnknownProvider().IssueURL("https://example.com/o/r", "42"); ok {
+		t.Errorf("unknown IssueURL() = %q, want none", got)
+	}
+}
@@ -0,0 +1,71 @@
+package gitopen
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Remote is a remote URL and the repository web page it leads to.
+type Remote struct {
+	// URL is the remote URL as given.
+	URL string
+	// Host is the web host, with the port of http(s) URLs, e.g. "github.com".
+	Host string
+	// Owner is the user, organisation or group path owning the repository.
+	Owner string
+	// Repo is the repository name.
+	Repo string
+	// WebURL is the web URL of the repository home page.
+	WebURL string
+}
+
+var scpRemoteURLPattern = regexp.MustCompile(`^(?:[^@]+@)?([^:]+):(.+)$`)
+
+// ParseRemoteURL parses an http(s), ssh or scp-like ("git@host:owner/repo")
+// remote URL. Other URLs fail with ErrUnsupportedURL.
+func ParseRemoteURL(rawURL string) (*Remote, error) {
+	unsupported := fmt.Errorf("%w: %s", ErrUnsupportedURL, rawURL)
+	raw := strings.TrimSpace(rawURL)
+	if raw == "" {
+		return nil, unsupported
+	}
+
+	var scheme, host, path string
+	if parsed, err := url.Parse(raw); err == nil && parsed.Host != "" && parsed.Scheme != "" {
+		// URL style: https://, http://, ssh:// or git+ssh://
+		path = strings.TrimPrefix(parsed.Path, "/")
+		switch parsed.Scheme {
+		case "http", "https":
+			scheme, host = parsed.Scheme, strings.TrimSuffix(parsed.Host, "/")
+		case "ssh", "git+ssh":
+			scheme, host = "https", parsed.Hostname()
+		default:
+			return nil, unsupported
+		}
+	} else if matches := scpRemoteURLPattern.FindStringSubmatch(raw); len(matches) == 3 {
+		scheme, host, path = "https", matches[1], strings.TrimPrefix(matches[2], "/")
+	}
+	if host == "" || path == "" {
+		return nil, unsupported
+	}
+
+	remote := &Remote{
+		URL:    rawURL,
+		Host:   host,
+		WebURL: fmt.Sprintf("%s://%s/%s", scheme, host, strings.TrimSuffix(path, ".git")),
+	}
+	remote.Owner, remote.Repo = splitRepositoryPath(strings.TrimSuffix(path, ".git"))
+	return remote, nil
+}
+
+// splitRepositoryPath splits the path of a repository web URL into its owner,
+// which may have several segments, and name.
+func splitRepositoryPath(path string) (owner, repo string) {
+	path = strings.Trim(path, "/")
+	if i := strings.LastIndex(path, "/"); i >= 0 {
+		return path[:i], path[i+1:]
+	}
+	return "", path
+}
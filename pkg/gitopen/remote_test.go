@@ -0,0 +1,40 @@
+package gitopen
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseRemoteURL(t *testing.T) {
+	tests := []struct {
+		name      string
+		rawURL    string
+		wantHost  string
+		wantOwner string
+		wantRepo  string
+		wantWeb   string
+	}{
+		{"https", "https://github.com/owner/repo.git", "github.com", "owner", "repo", "https://github.com/owner/repo"},
+		{"http with port", "http://git.example.com:8080/owner/repo", "git.example.com:8080", "owner", "repo", "http://git.example.com:8080/owner/repo"},
+		{"ssh", "ssh://git@gitlab.com:22/group/sub/repo.git", "gitlab.com", "group/sub", "repo", "https://gitlab.com/group/sub/repo"},
+		{"scp-like", "git@bitbucket.org:owner/repo.git", "bitbucket.org", "owner", "repo", "https://bitbucket.org/owner/repo"},
+		{"scp-like without user", "github.com:owner/repo", "github.com", "owner", "repo", "https://github.com/owner/repo"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRemoteURL(tt.rawURL)
+			if err != nil {
+				t.Fatalf("ParseRemoteURL() error = %v", err)
+			}
+			if got.URL != tt.rawURL || got.Host != tt.wantHost || got.Owner != tt.wantOwner || got.Repo != tt.wantRepo || got.WebURL != tt.wantWeb {
+				t.Errorf("ParseRemoteURL() = %+v, want host %q, owner %q, repo %q, web URL %q", got, tt.wantHost, tt.wantOwner, tt.wantRepo, tt.wantWeb)
+			}
+		})
+	}
+
+	for _, rawURL := range []string{"", "  ", "ftp://example.com/owner/repo.git", "/srv/repo.git", "https://github.com"} {
+		if _, err := ParseRemoteURL(rawURL); !errors.Is(err, ErrUnsupportedURL) {
+			t.Errorf("ParseRemoteURL(%q) error = %v, want %v", rawURL, err, ErrUnsupportedURL)
+		}
+	}
+}
@@ -0,0 +1,307 @@
+package gitopen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// defaultRemote is the remote resolved when no remote is configured.
+const defaultRemote = "origin"
+
+// ConfigSection is the git config section holding git-open's settings for a
+// repository, e.g. git-open.provider.
+const ConfigSection = "git-open"
+
+// Options are the settings git-open reads from its YAML config. Settings left
+// empty are read from the git-open.* keys of the repository's git config, like
+// git-open does; the zero value resolves a repository like git-open without a
+// YAML config.
+type Options struct {
+	// Remote is the remote to open; git-open.remote, or origin, when empty.
+	Remote string
+	// Provider is the name of the hosting service in Registry;
+	// git-open.provider, or the one detected from the remote URL, when empty.
+	Provider string
+	// DefaultBranch is the branch that resolves to the home page rather than
+	// a branch page; git-open.defaultBranch, or main and master, when empty.
+	DefaultBranch string
+	// WebURL replaces the web URL derived from the remote URL, e.g. for
+	// mirrors; git-open.webURL when empty.
+	WebURL string
+	// RemoteURL replaces the URL of the remote from the config.
+	RemoteURL string
+	// Config is the effective git config of the repository, read with
+	// ReadConfig when nil. Callers that have read it already pass it to
+	// save reading it again.
+	Config *Config
+	// SSHConfig resolves SSH host aliases in the remote URL, e.g. a Host
+	// github.com-work block whose HostName is github.com; aliases are kept
+	// when nil. git-open reads ~/.ssh/config with ReadSSHConfig.
+	SSHConfig *SSHConfig
+	// Registry holds the providers to detect and look up;
+	// NewRegistry(DefaultProviders()...) when nil.
+	Registry *Registry
+	// Discovery is how Resolve finds the repository, e.g.
+	// DiscoveryFromEnv(os.Getenv) to honour GIT_DIR like git does.
+	Discovery Discovery
+	// Logger traces the directories searched, the config files read and the
+	// choices made; nothing is traced when nil.
+	Logger *slog.Logger
+}
+
+// WithConfig returns o with its empty settings read from the git-open.* keys
+// of cfg, trimmed of spaces.
+func (o Options) WithConfig(cfg *Config) Options {
+	if cfg == nil {
+		return o
+	}
+	for key, field := range map[string]*string{
+		"remote":        &o.Remote,
+		"provider":      &o.Provider,
+		"defaultBranch": &o.DefaultBranch,
+		"webURL":        &o.WebURL,
+	} {
+		if value, ok := cfg.Get(ConfigSection, "", key); ok && *field == "" {
+			*field = strings.TrimSpace(value)
+		}
+	}
+	return o
+}
+
+// Target describes the repository page git-open resolved, exposing the fields
+// available to --format templates.
+type Target struct {
+	// Host is the web host of the repository, e.g. "github.com".
+	Host string `json:"host"`
+	// Owner is the user, organisation or group path owning the repository.
+	Owner string `json:"owner"`
+	// Repo is the repository name.
+	Repo string `json:"repo"`
+	// Branch is the current branch, empty when it cannot be determined.
+	Branch string `json:"branch"`
+	// SHA is the commit HEAD points to, empty when there is none.
+	SHA string `json:"sha"`
+	// Detached is whether HEAD points to a commit rather than a branch.
+	Detached bool `json:"detached"`
+	// Tag is a tag of the commit a detached HEAD points to, if any.
+	Tag string `json:"tag"`
+	// WebURL is the URL of the resolved page.
+	WebURL string `json:"webURL"`
+	// HomeURL is the URL of the repository home page.
+	HomeURL string `json:"homeURL"`
+	// RemoteURL is the URL of the remote the web URL was derived from, with
+	// its insteadOf rules and SSH host alias resolved.
+	RemoteURL string `json:"remoteURL"`
+	// Provider is the hosting service, e.g. "github" or "gitlab".
+	Provider string `json:"provider"`
+}
+
+// Resolve resolves the page of the repository Open finds in dir: the page of
+// the current branch, the home page on the default branch, or on a detached
+// HEAD the page of its tag or commit. Like git-open, it reads the effective
+// git config of the repository, with its system, global and included layers
+// and url.<base>.insteadOf rules.
+func Resolve(ctx context.Context, dir string, opts Options) (*Target, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	repo, err := Open(dir, opts.Discovery, opts.Logger)
+	if err != nil {
+		return nil, fmt.Errorf("error opening repository at %s: %w", dir, err)
+	}
+	return ResolveRepository(ctx, repo, opts)
+}
+
+// ResolveRepository is Resolve for a repository opened by the caller.
+func ResolveRepository(ctx context.Context, repo *git.Repository, opts Options) (*Target, error) {
+	logger := orDiscard(opts.Logger)
+	registry := opts.Registry
+	if registry == nil {
+		registry = NewRegistry(DefaultProviders()...)
+	}
+	cfg := opts.Config
+	if cfg == nil {
+		cfg = readRepositoryConfig(repo, logger)
+	}
+	opts = opts.WithConfig(cfg)
+
+	name := opts.Remote
+	if name == "" {
+		name = defaultRemote
+	}
+	remoteURL := opts.RemoteURL
+	if remoteURL == "" {
+		var err error
+		if remoteURL, err = RemoteURL(repo, cfg, name); err != nil {
+			return nil, err
+		}
+	}
+
+	if rewritten, ok := opts.SSHConfig.RewriteURL(remoteURL); ok {
+		logger.Debug("resolved SSH host alias", "url", remoteURL, "resolved_url", rewritten)
+		remoteURL = rewritten
+	}
+
+	target := &Target{RemoteURL: remoteURL}
+	if opts.WebURL != "" {
+		// The web URL of a mirror is all that is needed of it
+		logger.Debug("using configured web URL", "url", opts.WebURL)
+		target.HomeURL = strings.TrimSuffix(opts.WebURL, "/")
+		if u, err := url.Parse(target.HomeURL); err == nil {
+			target.Host = u.Host
+			target.Owner, target.Repo = splitRepositoryPath(u.Path)
+		}
+	} else {
+		parsed, err := ParseRemoteURL(remoteURL)
+		if err != nil {
+			return nil, err
+		}
+		logger.Debug("converted remote URL", "remote_url", remoteURL, "web_url", parsed.WebURL)
+		target.Host, target.Owner, target.Repo, target.HomeURL = parsed.Host, parsed.Owner, parsed.Repo, parsed.WebURL
+	}
+	target.WebURL = target.HomeURL
+
+	// A mirror's web host may be anything, so the provider is detected from
+	// the remote URL
+	provider, _ := registry.Detect(remoteURL)
+	if opts.Provider != "" {
+		var ok bool
+		if provider, ok = registry.Lookup(opts.Provider); !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownProvider, opts.Provider)
+		}
+		logger.Debug("using configured provider", "provider", provider.Name)
+	} else {
+		logger.Debug("detected provider", "provider", provider.Name, "remote_url", remoteURL)
+	}
+	target.Provider = provider.Name
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	head, err := repo.Head()
+	if errors.Is(err, plumbing.ErrReferenceNotFound) {
+		// A branch without commits is no branch yet, except in a bare
+		// repository, whose HEAD names the branch of a mirror or hub even
+		// when it is the only one without commits
+		ref, err := repo.Reference(plumbing.HEAD, false)
+		if err != nil || !ref.Target().IsBranch() {
+			return target, nil
+		}
+		if _, err := repo.Worktree(); errors.Is(err, git.ErrIsBareRepository) {
+			target.Branch = ref.Target().Short()
+			if !isDefaultBranch(target.Branch, opts.DefaultBranch) {
+				target.WebURL = provider.BranchURL(target.HomeURL, target.Branch)
+			}
+		}
+		return target, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting HEAD: %w", err)
+	}
+
+	target.SHA = head.Hash().String()
+	if head.Name().IsBranch() {
+		target.Branch = head.Name().Short()
+		if !isDefaultBranch(target.Branch, opts.DefaultBranch) {
+			target.WebURL = provider.BranchURL(target.HomeURL, target.Branch)
+		}
+		return target, nil
+	}
+	target.Detached = true
+	if target.Tag, err = CommitTag(ctx, repo, head.Hash()); err != nil {
+		return nil, err
+	}
+	if target.Tag != "" {
+		target.WebURL = provider.TagURL(target.HomeURL, target.Tag)
+	} else {
+		target.WebURL = provider.BranchURL(target.HomeURL, target.SHA)
+	}
+	return target, nil
+}
+
+// readRepositoryConfig reads the effective config of repo, or returns nil when
+// repo is not stored on disk or its config cannot be read, in which case
+// go-git's reading of the repository config is used.
+func readRepositoryConfig(repo *git.Repository, logger *slog.Logger) *Config {
+	gitDir := GitDir(repo)
+	if gitDir == "" {
+		return nil
+	}
+	commonDir, err := CommonGitDir(gitDir)
+	if err != nil {
+		logger.Debug("cannot read the git config", "error", err)
+		return nil
+	}
+	cfg, err := ReadConfig(gitDir, commonDir, logger)
+	if err != nil {
+		logger.Debug("cannot read the git config", "error", err)
+		return nil
+	}
+	return cfg
+}
+
+// RemoteURL returns the URL of the named remote of repo from its effective
+// config cfg, rewritten by url.<base>.insteadOf rules, or from go-git's
+// reading of the repository config when cfg is nil or lacks the remote.
+func RemoteURL(repo *git.Repository, cfg *Config, name string) (string, error) {
+	if cfg != nil {
+		if remoteURL, ok := cfg.RemoteURL(name); ok {
+			return remoteURL, nil
+		}
+	}
+	remote, err := repo.Remote(name)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", err, name)
+	}
+	urls := remote.Config().URLs
+	if len(urls) == 0 {
+		return "", fmt.Errorf("%w: %s has no URL", ErrNoRemote, name)
+	}
+	return urls[0], nil
+}
+
+// isDefaultBranch reports whether branch resolves to the home page.
+func isDefaultBranch(branch, defaultBranch string) bool {
+	if defaultBranch != "" {
+		return branch == defaultBranch
+	}
+	return branch == "main" || branch == "master"
+}
+
+// CommitTag returns the first tag, in name order, that points to the commit
+// hash, either directly or through an annotated tag, or "" when none does.
+func CommitTag(ctx context.Context, repo *git.Repository, hash plumbing.Hash) (string, error) {
+	tags, err := repo.Tags()
+	if err != nil {
+		return "", err
+	}
+	defer tags.Close()
+
+	var names []string
+	err = tags.ForEach(func(ref *plumbing.Reference) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		target := ref.Hash()
+		if tag, err := repo.TagObject(target); err == nil {
+			target = tag.Target
+		}
+		if target == hash {
+			names = append(names, ref.Name().Short())
+		}
+		return nil
+	})
+	if err != nil || len(names) == 0 {
+		return "", err
+	}
+	sort.Strings(names)
+	return names[0], nil
+}
@@ -0,0 +1,241 @@
+package gitopen
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/zhaochunqi/git-open/internal/testhelper"
+)
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		name      string
+		remoteURL string
+		branch    string
+		opts      Options
+		want      Target
+	}{
+		{
+			name:      "branch page",
+			remoteURL: "git@github.com:owner/repo.git",
+			branch:    "feature",
+			want:      Target{Host: "github.com", Owner: "owner", Repo: "repo", Branch: "feature", WebURL: "https://github.com/owner/repo/tree/feature", HomeURL: "https://github.com/owner/repo", RemoteURL: "git@github.com:owner/repo.git", Provider: "github"},
+		},
+		{
+			name:      "default branch",
+			remoteURL: "https://gitlab.com/group/repo.git",
+			branch:    "main",
+			want:      Target{Host: "gitlab.com", Owner: "group", Repo: "repo", Branch: "main", WebURL: "https://gitlab.com/group/repo", HomeURL: "https://gitlab.com/group/repo", RemoteURL: "https://gitlab.com/group/repo.git", Provider: "gitlab"},
+		},
+		{
+			name:      "configured default branch",
+			remoteURL: "https://gitlab.com/group/repo.git",
+			branch:    "main",
+			opts:      Options{DefaultBranch: "develop"},
+			want:      Target{Host: "gitlab.com", Owner: "group", Repo: "repo", Branch: "main", WebURL: "https://gitlab.com/group/repo/-/tree/main", HomeURL: "https://gitlab.com/group/repo", RemoteURL: "https://gitlab.com/group/repo.git", Provider: "gitlab"},
+		},
+		{
+			name:      "provider and web URL",
+			remoteURL: "git@mirror.example.com:team/repo.git",
+			branch:    "feature",
+			opts:      Options{Provider: "bitbucket", WebURL: "https://code.example.com/team/repo/"},
+			want:      Target{Host: "code.example.com", Owner: "team", Repo: "repo", Branch: "feature", WebURL: "https://code.example.com/team/repo/src/feature", HomeURL: "https://code.example.com/team/repo", RemoteURL: "git@mirror.example.com:team/repo.git", Provider: "bitbucket"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, cleanup := testhelper.SetupTestRepo(t, tt.remoteURL, tt.branch)
+			defer cleanup()
+
+			got, err := Resolve(context.Background(), dir, tt.opts)
+			if err != nil {
+				t.Fatalf("Resolve() error = %v", err)
+			}
+			tt.want.SHA = got.SHA
+			if got.SHA == "" || *got != tt.want {
+				t.Errorf("Resolve() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolve_DetachedHead(t *testing.T) {
+	dir, cleanup := testhelper.SetupTestRepo(t, "git@github.com:owner/repo.git", "main")
+	defer cleanup()
+	repo, err := git.PlainOpen(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Storer.SetReference(plumbing.NewHashReference(plumbing.HEAD, head.Hash())); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ResolveRepository(context.Background(), repo, Options{})
+	if err != nil {
+		t.Fatalf("ResolveRepository() error = %v", err)
+	}
+	if want := "https://github.com/owner/repo/tree/" + head.Hash().String(); !got.Detached || got.WebURL != want {
+		t.Errorf("ResolveRepository() = %+v, want a detached target at %s", got, want)
+	}
+
+	if _, err := repo.CreateTag("v1.2.3", head.Hash(), nil); err != nil {
+		t.Fatal(err)
+	}
+	got, err = ResolveRepository(context.Background(), repo, Options{})
+	if err != nil {
+		t.Fatalf("ResolveRepository() error = %v", err)
+	}
+	if want := "https://github.com/owner/repo/releases/tag/v1.2.3"; got.Tag != "v1.2.3" || got.WebURL != want {
+		t.Errorf("ResolveRepository() = %+v, want tag v1.2.3 at %s", got, want)
+	}
+}
+
+func TestResolve_Errors(t *testing.T) {
+	t.Run("not a repository", func(t *testing.T) {
+		dir := t.TempDir()
+		if _, err := Resolve(context.Background(), dir, Options{}); !errors.Is(err, ErrNotRepository) {
+			t.Errorf("Resolve() error = %v, want %v", err, ErrNotRepository)
+		}
+	})
+
+	t.Run("no remote", func(t *testing.T) {
+		dir, cleanup := testhelper.SetupTestRepo(t, "git@github.com:owner/repo.git", "main")
+		defer cleanup()
+		if _, err := Resolve(context.Background(), dir, Options{Remote: "upstream"}); !errors.Is(err, ErrNoRemote) {
+			t.Errorf("Resolve() error = %v, want %v", err, ErrNoRemote)
+		}
+	})
+
+	t.Run("unsupported URL", func(t *testing.T) {
+		dir, cleanup := testhelper.SetupTestRepo(t, "/srv/repo.git", "main")
+		defer cleanup()
+		if _, err := Resolve(context.Background(), dir, Options{}); !errors.Is(err, ErrUnsupportedURL) {
+			t.Errorf("Resolve() error = %v, want %v", err, ErrUnsupportedURL)
+		}
+	})
+
+	t.Run("unknown provider", func(t *testing.T) {
+		dir, cleanup := testhelper.SetupTestRepo(t, "git@github.com:owner/repo.git", "main")
+		defer cleanup()
+		if _, err := Resolve(context.Background(), dir, Options{Provider: "gitea"}); !errors.Is(err, ErrUnknownProvider) {
+			t.Errorf("Resolve() error = %v, want %v", err, ErrUnknownProvider)
+		}
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		dir, cleanup := testhelper.SetupTestRepo(t, "git@github.com:owner/repo.git", "main")
+		defer cleanup()
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if _, err := Resolve(ctx, dir, Options{}); !errors.Is(err, context.Canceled) {
+			t.Errorf("Resolve() error = %v, want %v", err, context.Canceled)
+		}
+	})
+}
+
+func TestResolve_UnsupportedExtension(t *testing.T) {
+	dir, cleanup := testhelper.SetupTestRepo(t, "git@github.com:owner/repo.git", "feature")
+	defer cleanup()
+	appendConfig(t, filepath.Join(dir, ".git", "config"), "[extensions]\n\tworktreeConfig = true\n")
+	if err := os.MkdirAll(filepath.Join(dir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Resolve(context.Background(), filepath.Join(dir, "sub"), Options{})
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if want := "https://github.com/owner/repo/tree/feature"; got.WebURL != want {
+		t.Errorf("Resolve().WebURL = %q, want %q", got.WebURL, want)
+	}
+}
+
+func TestResolve_GitConfig(t *testing.T) {
+	dir, cleanup := testhelper.SetupTestRepo(t, "gh:owner/repo", "main")
+	defer cleanup()
+	appendConfig(t, filepath.Join(dir, ".git", "config"), "[git-open]\n\tprovider = gitlab\n\tdefaultBranch = develop\n")
+	global := filepath.Join(t.TempDir(), "global")
+	if err := os.WriteFile(global, []byte("[url \"git@github.com:\"]\n\tinsteadOf = gh:\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GIT_CONFIG_GLOBAL", global)
+
+	got, err := Resolve(context.Background(), dir, Options{})
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if want := "https://github.com/owner/repo/-/tree/main"; got.WebURL != want || got.RemoteURL != "git@github.com:owner/repo" || got.Provider != "gitlab" {
+		t.Errorf("Resolve() = %+v, want %s from the rewritten remote URL with the git-open.* keys applied", got, want)
+	}
+
+	got, err = Resolve(context.Background(), dir, Options{Provider: "github", DefaultBranch: "main"})
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if want := "https://github.com/owner/repo"; got.WebURL != want || got.Provider != "github" {
+		t.Errorf("Resolve() = %+v, want %s with the options over the git-open.* keys", got, want)
+	}
+}
+
+func TestResolve_GitDirEnvironment(t *testing.T) {
+	dir, cleanup := testhelper.SetupTestRepo(t, "git@github.com:owner/repo.git", "feature")
+	defer cleanup()
+	other := t.TempDir()
+	d := Discovery{GitDir: filepath.Join(dir, ".git"), WorkTree: dir}
+
+	got, err := Resolve(context.Background(), other, Options{Discovery: d})
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if want := "https://github.com/owner/repo/tree/feature"; got.WebURL != want {
+		t.Errorf("Resolve().WebURL = %q, want %q", got.WebURL, want)
+	}
+}
+
+func TestResolve_BareWithoutCommits(t *testing.T) {
+	bareDir := filepath.Join(t.TempDir(), "repo.git")
+	bare, err := git.PlainInit(bareDir, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := bare.CreateRemote(&config.RemoteConfig{Name: "origin", URLs: []string{"git@github.com:owner/repo.git"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := bare.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, "refs/heads/trunk")); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Resolve(context.Background(), bareDir, Options{})
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if want := "https://github.com/owner/repo/tree/trunk"; got.Branch != "trunk" || got.WebURL != want {
+		t.Errorf("Resolve() = %+v, want branch trunk at %s", got, want)
+	}
+}
+
+// appendConfig appends content to the git config file at path.
+func appendConfig(t *testing.T, path, content string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString(content); err != nil {
+		f.Close()
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
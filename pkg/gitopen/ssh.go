@@ -0,0 +1,148 @@
+package gitopen
+
+import (
+	"bufio"
+	"io"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// SSHConfig holds the Host blocks of an OpenSSH client config, which is all
+// resolving SSH host aliases in remote URLs needs, e.g. a
+//
+//	Host github.com-work
+//		HostName github.com
+//
+// block for a second GitHub account.
+type SSHConfig struct {
+	blocks []sshHostBlock
+}
+
+// sshHostBlock is a Host block and the HostName it sets, if any.
+type sshHostBlock struct {
+	patterns []string
+	hostName string
+}
+
+// ReadSSHConfig reads the OpenSSH client config at path, e.g. ~/.ssh/config.
+// A missing file is an empty config.
+func ReadSSHConfig(path string) (*SSHConfig, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return &SSHConfig{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseSSHConfig(f)
+}
+
+// ParseSSHConfig parses an OpenSSH client config. Match blocks and Include
+// directives are skipped.
+func ParseSSHConfig(r io.Reader) (*SSHConfig, error) {
+	c := &SSHConfig{}
+	var block *sshHostBlock
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		keyword, value, _ := strings.Cut(line, " ")
+		if k, v, ok := strings.Cut(line, "="); ok && len(k) < len(keyword) {
+			keyword, value = k, v
+		}
+		value = strings.TrimSpace(value)
+
+		switch strings.ToLower(strings.TrimSpace(keyword)) {
+		case "host":
+			c.blocks = append(c.blocks, sshHostBlock{patterns: strings.Fields(value)})
+			block = &c.blocks[len(c.blocks)-1]
+		case "match":
+			block = nil
+		case "hostname":
+			// The first value obtained is used
+			if block != nil && block.hostName == "" {
+				block.hostName = strings.Trim(value, `"`)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// HostName returns the host SSH connects to for host: the HostName of the
+// first Host block matching it, with %h standing for host, and false when no
+// block sets one.
+func (c *SSHConfig) HostName(host string) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	for _, block := range c.blocks {
+		if block.hostName != "" && matchSSHPatterns(block.patterns, host) {
+			return strings.ReplaceAll(block.hostName, "%h", host), true
+		}
+	}
+	return "", false
+}
+
+// RewriteURL returns rawURL with the host of an SSH remote URL, scp-like or
+// ssh://, replaced by the host SSH connects to for it, and whether it was.
+func (c *SSHConfig) RewriteURL(rawURL string) (string, bool) {
+	if parsed, err := url.Parse(rawURL); err == nil && parsed.Scheme != "" && parsed.Host != "" {
+		if parsed.Scheme != "ssh" && parsed.Scheme != "git+ssh" {
+			return rawURL, false
+		}
+		hostName, ok := c.HostName(parsed.Hostname())
+		if !ok || hostName == parsed.Hostname() {
+			return rawURL, false
+		}
+		if port := parsed.Port(); port != "" {
+			hostName += ":" + port
+		}
+		parsed.Host = hostName
+		return parsed.String(), true
+	}
+
+	matches := scpRemoteURLPattern.FindStringSubmatchIndex(rawURL)
+	if matches == nil {
+		return rawURL, false
+	}
+	host := rawURL[matches[2]:matches[3]]
+	hostName, ok := c.HostName(host)
+	if !ok || hostName == host {
+		return rawURL, false
+	}
+	return rawURL[:matches[2]] + hostName + rawURL[matches[3]:], true
+}
+
+// matchSSHPatterns reports whether host matches one of patterns and none of
+// the negated ones, like ssh_config's Host does.
+func matchSSHPatterns(patterns []string, host string) bool {
+	matched := false
+	for _, pattern := range patterns {
+		negated := strings.HasPrefix(pattern, "!")
+		if !matchSSHPattern(strings.TrimPrefix(pattern, "!"), host) {
+			continue
+		}
+		if negated {
+			return false
+		}
+		matched = true
+	}
+	return matched
+}
+
+// matchSSHPattern matches host against an ssh_config pattern, in which *
+// matches any run of characters and ? any one, ignoring case.
+func matchSSHPattern(pattern, host string) bool {
+	expr := regexp.QuoteMeta(pattern)
+	expr = strings.NewReplacer(`\*`, ".*", `\?`, ".").Replace(expr)
+	matched, _ := regexp.MatchString("(?i)^"+expr+"$", host)
+	return matched
+}
@@ -0,0 +1,65 @@
+package gitopen
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSSHConfig_RewriteURL(t *testing.T) {
+	config, err := ParseSSHConfig(strings.NewReader(`
+# Work account
+Host github.com-work gh-work
+	User git
+	HostName github.com
+
+Host *.internal !legacy.internal
+	HostName=%h.example.com
+
+Match host other
+	HostName ignored.example.com
+
+Host gitlab-*
+	HostName gitlab.com
+	HostName second.example.com
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		rawURL string
+		want   string
+	}{
+		{"git@github.com-work:owner/repo.git", "git@github.com:owner/repo.git"},
+		{"gh-work:owner/repo", "github.com:owner/repo"},
+		{"ssh://git@GitHub.com-Work:22/owner/repo.git", "ssh://git@github.com:22/owner/repo.git"},
+		{"git@code.internal:owner/repo.git", "git@code.internal.example.com:owner/repo.git"},
+		{"git@legacy.internal:owner/repo.git", "git@legacy.internal:owner/repo.git"},
+		{"git@gitlab-mine:group/repo.git", "git@gitlab.com:group/repo.git"},
+		{"git@other:owner/repo.git", "git@other:owner/repo.git"},
+		{"https://github.com-work/owner/repo.git", "https://github.com-work/owner/repo.git"},
+		{"/srv/repo.git", "/srv/repo.git"},
+	}
+	for _, tt := range tests {
+		got, rewritten := config.RewriteURL(tt.rawURL)
+		if got != tt.want || rewritten != (tt.want != tt.rawURL) {
+			t.Errorf("RewriteURL(%q) = %q, %v, want %q", tt.rawURL, got, rewritten, tt.want)
+		}
+	}
+
+	var none *SSHConfig
+	if got, rewritten := none.RewriteURL("git@github.com-work:owner/repo.git"); rewritten || got != "git@github.com-work:owner/repo.git" {
+		t.Errorf("nil RewriteURL() = %q, %v, want the URL kept", got, rewritten)
+	}
+}
+
+func TestReadSSHConfig_Missing(t *testing.T) {
+	config, err := ReadSSHConfig(filepath.Join(t.TempDir(), "config"))
+	if err != nil {
+		t.Fatalf("ReadSSHConfig() error = %v", err)
+	}
+	if _, ok := config.HostName("github.com"); ok {
+		t.Error("HostName() ok = true for a missing config, want false")
+	}
+}